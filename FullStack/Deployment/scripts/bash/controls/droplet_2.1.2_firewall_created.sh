@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure a Firewall is Created
 set -euo pipefail
 
 CONTROL_ID="2.1.2"
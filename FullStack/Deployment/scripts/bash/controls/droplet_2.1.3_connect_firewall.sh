@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Droplets are Connected to Firewall and VPC
 set -euo pipefail
 
 CONTROL_ID="2.1.3"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure OS Upgrade Policy
 set -euo pipefail
 
 CONTROL_ID="2.1.4"
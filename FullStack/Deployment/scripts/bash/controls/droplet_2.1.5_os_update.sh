@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Periodic Security Updates are Configured
 set -euo pipefail
 
 CONTROL_ID="2.1.5"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure auditd is Enabled
 set -euo pipefail
 
 CONTROL_ID="2.1.6"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Only SSH Key Authentication is Allowed
 set -euo pipefail
 
 CONTROL_ID="2.1.7"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Unused SSH Keys are Removed
 set -euo pipefail
 
 CONTROL_ID="2.1.8"
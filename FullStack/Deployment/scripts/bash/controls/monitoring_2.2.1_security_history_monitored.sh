@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Security History is Monitored
 set -euo pipefail
 
 CONTROL_ID="2.2.1"
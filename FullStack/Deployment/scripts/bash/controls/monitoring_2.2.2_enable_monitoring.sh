@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Monitoring is Enabled and an Alert Policy Exists
 set -euo pipefail
 
 CONTROL_ID="2.2.2"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Manage Spaces Access Keys via Env or Secret Store
 set -euo pipefail
 
 CONTROL_ID="2.3.2"
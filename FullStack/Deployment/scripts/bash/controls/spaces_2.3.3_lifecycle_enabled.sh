@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure a Lifecycle Policy Exists for the Bucket
 set -euo pipefail
 
 CONTROL_ID="2.3.3"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure the Bucket is Private
 set -euo pipefail
 
 CONTROL_ID="2.3.4"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure CDN is Enabled for the Spaces Bucket
 set -euo pipefail
 
 CONTROL_ID="2.3.5"
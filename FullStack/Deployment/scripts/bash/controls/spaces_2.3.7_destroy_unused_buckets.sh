@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Unused Spaces Buckets are Removed
 set -euo pipefail
 
 CONTROL_ID="2.3.7"
@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
+# cisctl-title: Ensure Volumes are Encrypted and Mounted Safely
 set -euo pipefail
 
 CONTROL_ID="2.4.1"
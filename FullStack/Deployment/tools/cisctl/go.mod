@@ -1,11 +1,13 @@
 module cisctl
 
-go 1.23.0
+go 1.24.0
 
 require (
 	github.com/digitalocean/godo v1.170.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	golang.org/x/crypto v0.41.0
 	golang.org/x/oauth2 v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,14 @@
+// Package assets embeds copies of the deployment docs that cisctl ships in
+// its binary. Run `go generate ./...` after editing docs/controls or
+// scripts/slack/remediation.json.
+package assets
+
+import "embed"
+
+//go:generate sh -c "rm -rf controls && cp -R ../../../../docs/controls controls && cp ../../../../scripts/slack/remediation.json remediation.json"
+
+//go:embed controls
+var ControlDocs embed.FS
+
+//go:embed remediation.json
+var RemediationJSON []byte
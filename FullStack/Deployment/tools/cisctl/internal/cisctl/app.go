@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
@@ -13,11 +14,60 @@ import (
 )
 
 type App struct {
-	controls []Control
+	controls     []Control
+	planControls []PlanControl
+	loadControls ControlLoader
+	// added records that the configured controls were added to controls.
+	added bool
 }
 
-func NewApp(controls []Control) *App {
-	return &App{controls: controls}
+func NewApp(controls []Control, planControls []PlanControl, loadControls ControlLoader) *App {
+	return &App{controls: controls, planControls: planControls, loadControls: loadControls}
+}
+
+// addControls adds the controls defined in cfg.ControlsDir and the exec
+// controls of cfg.ExecControls to the built-in ones, once per process. A
+// defined control whose ID is taken is an error. With cfg.ExecSkipDefined
+// such an exec control is skipped with a warning instead, so that
+// scripts/bash/controls/*.sh adds only the scripts cisctl has no Go control
+// for.
+func (a *App) addControls(cfg Config) error {
+	if a.added {
+		return nil
+	}
+	taken := func(id string) bool {
+		return slices.ContainsFunc(a.controls, func(c Control) bool { return c.ID() == id })
+	}
+	if dir := strings.TrimSpace(cfg.ControlsDir); dir != "" && a.loadControls != nil {
+		loaded, err := a.loadControls(dir)
+		if err != nil {
+			return err
+		}
+		for _, c := range loaded {
+			if taken(c.ID()) {
+				return fmt.Errorf("%s: control %s is already defined", dir, c.ID())
+			}
+			a.controls = append(a.controls, c)
+		}
+	}
+	if len(cfg.ExecControls) > 0 {
+		execs, err := LoadExecControls(cfg.RootDir, cfg.ExecControls)
+		if err != nil {
+			return err
+		}
+		for _, c := range execs {
+			if !taken(c.ID()) {
+				a.controls = append(a.controls, c)
+				continue
+			}
+			if !cfg.ExecSkipDefined {
+				return fmt.Errorf("%s: control %s is already defined (use --exec-skip-defined to skip it)", c.Path, c.ID())
+			}
+			fmt.Fprintf(os.Stderr, "Skipping exec control %s: control %s is already defined\n", c.Path, c.ID())
+		}
+	}
+	a.added = true
+	return nil
 }
 
 func (a *App) Run(ctx context.Context, args []string) int {
@@ -31,9 +81,31 @@ func (a *App) Run(ctx context.Context, args []string) int {
 		a.printUsage()
 		return 0
 	case "list":
-		return a.runList()
+		return a.runList(args[1:])
+	case "describe":
+		return a.runDescribe(args[1:])
+	case "config":
+		return a.runConfig(ctx, args[1:])
+	case "drift":
+		return a.runDrift(ctx, args[1:])
+	case "doctor":
+		return a.runDoctor(ctx, args[1:])
+	case "inventory":
+		return a.runInventory(ctx, args[1:])
 	case "run":
 		return a.runRun(ctx, args[1:])
+	case "scan-plan":
+		return a.runScanPlan(args[1:])
+	case "report":
+		return a.runReport(args[1:])
+	case "notify":
+		return a.runNotify(ctx, args[1:])
+	case "remediate":
+		return a.runRemediate(ctx, args[1:])
+	case "serve":
+		return a.runServe(ctx, args[1:])
+	case "watch":
+		return a.runWatch(ctx, args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
 		a.printUsage()
@@ -41,7 +113,23 @@ func (a *App) Run(ctx context.Context, args []string) int {
 	}
 }
 
-func (a *App) runList() int {
+func (a *App) runList(args []string) int {
+	fs := flag.NewFlagSet("cisctl list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	common := addConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := common.loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
+		return 2
+	}
+
 	controls := slices.Clone(a.controls)
 	slices.SortFunc(controls, func(a Control, b Control) int {
 		return strings.Compare(a.ID(), b.ID())
@@ -56,140 +144,128 @@ func (a *App) runRun(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("cisctl run", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
+	common := addCommonFlags(fs)
 	var (
-		flagRoot     = fs.String("root", "", "Deployment root directory (defaults to auto-detect)")
-		flagEnvTag   = fs.String("env-tag", "", "DigitalOcean tag name to scope droplets (defaults ENV_TAG or env:demo)")
-		flagControls = fs.String("controls", "", "Comma-separated list of control IDs (default: all)")
-		flagDotEnv   = fs.String("dotenv", "", "Path to .env file (default: <root>/.env if exists)")
 		flagJSON     = fs.Bool("json", false, "Print JSON report to stdout")
+		flagMetrics  = fs.String("metrics-file", "", "Write Prometheus metrics to this file")
+		flagTextfile = fs.String("textfile-dir", "", "Write "+TextfileName+" into this node_exporter textfile collector directory")
 	)
 
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 
-	rootDir := strings.TrimSpace(*flagRoot)
-	if rootDir == "" {
-		rootDir = FindDeploymentRoot()
-	}
-	rootDir, _ = filepath.Abs(rootDir)
-
-	dotEnvPath := strings.TrimSpace(*flagDotEnv)
-	if dotEnvPath == "" {
-		dotEnvPath = filepath.Join(rootDir, ".env")
-	}
-	_ = LoadDotEnv(dotEnvPath)
-
-	cfg, err := LoadConfig(rootDir, *flagEnvTag)
+	cfg, err := common.loadRunConfig(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
 		return 2
 	}
-
-	selectedControls := a.controls
-	if strings.TrimSpace(*flagControls) != "" {
-		want := map[string]bool{}
-		for _, id := range strings.Split(*flagControls, ",") {
-			id = strings.TrimSpace(id)
-			if id == "" {
-				continue
-			}
-			want[id] = true
-		}
-		filtered := make([]Control, 0, len(a.controls))
-		for _, c := range a.controls {
-			if want[c.ID()] {
-				filtered = append(filtered, c)
-			}
-		}
-		selectedControls = filtered
-		if len(selectedControls) == 0 {
-			fmt.Fprintf(os.Stderr, "No controls matched: %s\n", *flagControls)
-			return 2
-		}
-	}
-
-	if err := os.MkdirAll(cfg.LogDir, 0o755); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create log dir: %v\n", err)
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
 		return 2
 	}
-	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create report dir: %v\n", err)
+
+	selectedControls, err := a.selectControls(strings.Join(cfg.Controls, ","), cfg.Local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return 2
 	}
 
-	doClient, err := NewDOClient(cfg.DOAccessToken)
+	sess, err := newSession(cfg, "")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to init DO client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return 2
 	}
+	defer sess.Close()
 
-	sshRunner, err := NewSSHRunner(cfg)
+	report, reportPath, err := a.executeRun(ctx, sess, selectedControls)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to init SSH runner: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return 2
 	}
 
-	startedAt := time.Now().UTC()
-	report := Report{
-		Timestamp: startedAt,
-		EnvTag:    cfg.EnvTag,
-		RootDir:   cfg.RootDir,
-		Tool: ToolInfo{
-			Name:    "cisctl",
-			Version: "0.1.0",
-		},
+	if p := strings.TrimSpace(*flagMetrics); p != "" {
+		if err := writeFileAtomic(p, func(w io.Writer) error { return WritePrometheus(w, report) }); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write metrics: %v\n", err)
+			return 2
+		}
 	}
-
-	overallFail := false
-	for _, c := range selectedControls {
-		result := a.runOneControl(ctx, cfg, doClient, sshRunner, c)
-		report.Results = append(report.Results, result)
-		if !result.Pass {
-			overallFail = true
+	if dir := strings.TrimSpace(*flagTextfile); dir != "" {
+		if _, err := WriteTextfile(dir, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write textfile metrics: %v\n", err)
+			return 2
 		}
 	}
 
+	if *flagJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		fmt.Printf("\nReport: %s\nRun log: %s\n", reportPath, sess.logPath)
+	}
+
+	if report.Summary.Fail > 0 {
+		return 1
+	}
+	return 0
+}
+
+// executeRun runs the controls in order and writes the report into
+// cfg.ReportDir.
+func (a *App) executeRun(ctx context.Context, sess *session, controls []Control) (Report, string, error) {
+	sess.log.Info("run started", "env_tag", sess.cfg.EnvTag, "controls", len(controls), "token_source", sess.cfg.TokenSource.String(), "local", sess.cfg.Local)
+
+	report := sess.newReport()
+	for _, c := range controls {
+		report.Results = append(report.Results, a.runOneControl(ctx, sess, c))
+	}
+
 	report.Summary = Summarize(report.Results)
+	sess.log.Info("run finished", "total", report.Summary.Total, "pass", report.Summary.Pass, "fail", report.Summary.Fail)
 
-	reportPath := filepath.Join(cfg.ReportDir, fmt.Sprintf("cisctl_report_%s.json", startedAt.Format("20060102150405")))
+	reportPath := filepath.Join(sess.cfg.ReportDir, fmt.Sprintf("cisctl_report_%s.json", sess.startedAt.Format("20060102150405")))
 	if err := WriteJSONFile(reportPath, report); err != nil {
+		return report, "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return report, reportPath, nil
+}
+
+// finishReport summarizes report, writes it to path and prints it as JSON or
+// prints its path. It returns the exit code of commands that evaluate
+// controls without a session: 1 when a control fails.
+func finishReport(report Report, path string, asJSON bool) int {
+	report.Summary = Summarize(report.Results)
+	if err := WriteJSONFile(path, report); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
 		return 2
 	}
-
-	if *flagJSON {
+	if asJSON {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		_ = enc.Encode(report)
 	} else {
-		fmt.Printf("\nReport: %s\n", reportPath)
+		fmt.Printf("\nReport: %s\n", path)
 	}
-
-	if overallFail {
+	if report.Summary.Fail > 0 {
 		return 1
 	}
 	return 0
 }
 
-func (a *App) runOneControl(ctx context.Context, cfg Config, doClient *DOClient, sshRunner *SSHRunner, c Control) ControlResult {
+func (a *App) runOneControl(ctx context.Context, sess *session, c Control) ControlResult {
+	cfg := sess.cfg
 	controlStart := time.Now().UTC()
-	logger, logPath, err := NewControlLogger(cfg.LogDir, c.ID(), controlStart)
+	logger, logPath, err := sess.log.NewControlLogger(c.ID(), controlStart)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to init logger for %s: %v\n", c.ID(), err)
 	}
+	defer logger.Close()
 
-	deps := Deps{
-		Config: cfg,
-		DO:     doClient,
-		SSH:    sshRunner,
-		Log:    logger,
-	}
+	deps := sess.deps(logger)
 
-	if logger != nil {
-		logger.Infof("START %s - %s (env_tag=%s)", c.ID(), c.Title(), cfg.EnvTag)
-	}
-	fmt.Printf("[%s] %s ...\n", c.ID(), c.Title())
+	logger.Info("control started", "title", c.Title(), "env_tag", cfg.EnvTag)
+	fmt.Fprintf(sess.out, "[%s] %s ...\n", c.ID(), c.Title())
 
 	outcome, runErr := c.Run(ctx, deps)
 	finishedAt := time.Now().UTC()
@@ -204,7 +280,6 @@ func (a *App) runOneControl(ctx context.Context, cfg Config, doClient *DOClient,
 	}
 
 	if runErr != nil {
-		result.Pass = false
 		result.Error = runErr.Error()
 		result.Findings = append(result.Findings, Finding{
 			ResourceType: "control",
@@ -213,58 +288,114 @@ func (a *App) runOneControl(ctx context.Context, cfg Config, doClient *DOClient,
 		})
 	} else {
 		result.Findings = outcome.Findings
-		result.Pass = true
-		for _, f := range outcome.Findings {
-			if !f.Pass {
-				result.Pass = false
-				break
-			}
-		}
 	}
+	sess.waivers.Apply(&result, func(f Finding) []string { return sess.findingTags(ctx, f) }, finishedAt)
 
-	if logger != nil {
-		if result.Pass {
-			logger.Infof("PASS %s", c.ID())
-		} else {
-			logger.Errorf("FAIL %s", c.ID())
+	if runErr != nil {
+		logger.Error("control error", "error", runErr.Error())
+	}
+	for _, f := range result.Findings {
+		switch {
+		case f.Waiver == nil:
+		case f.Waiver.Expired:
+			logger.WithFinding(f).Warn("waiver expired", "expires", f.Waiver.Expires, "approver", f.Waiver.Approver)
+		default:
+			logger.WithFinding(f).Info("finding waived", "expires", f.Waiver.Expires, "approver", f.Waiver.Approver)
 		}
-		logger.Infof("END %s duration=%s", c.ID(), finishedAt.Sub(controlStart).String())
 	}
-
+	duration := finishedAt.Sub(controlStart).String()
 	if result.Pass {
-		fmt.Printf("  PASS [%s]\n", c.ID())
+		logger.Info("control finished", "status", result.Status, "duration", duration)
 	} else {
-		fmt.Printf("  FAIL [%s]\n", c.ID())
-		failCount := 0
+		logger.Error("control finished", "status", result.Status, "duration", duration)
+	}
+
+	printResult(sess.out, result, logPath)
+	return result
+}
+
+// printResult prints a control's status and up to five of its failing or not
+// applicable findings; seeAlso names where the rest are.
+func printResult(w io.Writer, result ControlResult, seeAlso string) {
+	fmt.Fprintf(w, "  %s [%s]\n", result.Status, result.ControlID)
+	if result.Status != StatusPass {
+		shown := 0
 		for _, f := range result.Findings {
-			if !f.Pass {
-				failCount++
-				if failCount > 5 {
-					fmt.Printf("  ... and more (see %s)\n", logPath)
-					break
-				}
-				if f.ResourceName != "" {
-					fmt.Printf("  - %s: %s\n", f.ResourceName, f.Reason)
-				} else {
-					fmt.Printf("  - %s\n", f.Reason)
-				}
+			if f.Pass && !f.NotApplicable {
+				continue
 			}
+			shown++
+			if shown > 5 {
+				fmt.Fprintf(w, "  ... and more (see %s)\n", seeAlso)
+				break
+			}
+			line := f.Reason
+			if f.ResourceName != "" {
+				line = f.ResourceName + ": " + f.Reason
+			}
+			switch {
+			case f.Waiver == nil:
+			case f.Waiver.Expired:
+				line += fmt.Sprintf(" (waiver EXPIRED %s)", f.Waiver.Expires)
+			default:
+				line += fmt.Sprintf(" (waived until %s by %s)", f.Waiver.Expires, f.Waiver.Approver)
+			}
+			fmt.Fprintf(w, "  - %s\n", line)
 		}
 	}
-	return result
 }
 
 func (a *App) printUsage() {
 	fmt.Print(`cisctl - DigitalOcean CIS demo checks
 
 Usage:
-  cisctl list
+  cisctl list [--controls-dir <dir>]
+  cisctl describe [--controls-dir <dir>] <control-id>
+  cisctl config show [--profile <name>] [--config <file>]
+  cisctl doctor [--root <dir>] [--env-tag <tag>] [--dotenv <path>]
+  cisctl drift --state <terraform.tfstate> [--env-tag <tag>] [--json]
+  cisctl inventory [--env-tag <tag>] [--format table|json|csv] [--blind-spots]
+  cisctl notify slack [--report <file>] [--webhook-url <url>] [--run-url <url>] [--job-status <status>]
+  cisctl notify webhook [--url <url>]... [--mode report|finding] [--secret <key>] [--header 'Name: value']... [--only-failures] [--baseline <old.json>]
+  cisctl remediate [--controls <ids>] [--firewall <name|id>] [--approve]
+  cisctl report schema
+  cisctl report validate <file>
+  cisctl report diff [--format text|json|markdown] <old.json> <new.json>
+  cisctl scan-plan [--controls <ids>] [--waivers <file>] [--json] <plan.json>
+  cisctl serve [--listen <addr>] [--concurrency <n>] [--queue-size <n>] [--token <token>]
+  cisctl watch (--interval <duration> | --cron <expr> [--utc]) [--controls <ids>] [--slack]
+               [--webhook <url>]... [--hook <cmd>]
   cisctl run [--root <dir>] [--env-tag <tag>] [--controls <ids>] [--dotenv <path>] [--json]
+             [--metrics-file <path>] [--textfile-dir <dir>]
+             [--log-level <level>] [--log-format text|json]
+             [--config <file>] [--profile <name>]
+             [--include-tags <tags>] [--exclude-tags <tags>] [--name-regex <re>]
+             [--regions <slugs>] [--project <name|id>]
+             [--hosts do|ansible:<hosts.ini>|static:<hosts.yaml>] [--host-groups <groups>] [--local]
+             [--controls-dir <dir>] [--exec-controls <paths>]
+             [--token-file <path> | --token-command <cmd> | --context <doctl-context>]
 
 Examples (run from FullStack/Deployment):
   go run ./tools/cisctl list
   go run ./tools/cisctl run --env-tag env:demo
   go run ./tools/cisctl run --controls 2.1.1,2.1.6
+  go run ./tools/cisctl run --env-tag env:prod --regions sgp1 --exclude-tags role:bastion
+  go run ./tools/cisctl run --profile prod
+  cisctl run --local --json > /var/log/cisctl.json
+  go run ./tools/cisctl run --controls 2.1.4,2.1.5,2.1.6,2.1.7 --hosts ansible:ansible/inventory/hosts.ini
+  go run ./tools/cisctl run --local --controls-dir controls.d --controls custom.1.1
+  go run ./tools/cisctl run --exec-controls 'scripts/bash/controls/*.sh'
+  go run ./tools/cisctl config show --profile prod
+  go run ./tools/cisctl doctor --token-command 'pass show do/token'
+  go run ./tools/cisctl run --textfile-dir /var/lib/node_exporter/textfile_collector
+  go run ./tools/cisctl remediate --controls 2.1.1 --approve
+  terraform -chdir=terraform/envs/demo show -json tfplan > plan.json && go run ./tools/cisctl scan-plan plan.json
+  terraform -chdir=terraform/envs/demo state pull > demo.tfstate && go run ./tools/cisctl drift --state demo.tfstate
+  go run ./tools/cisctl inventory --format csv > inventory.csv
+  go run ./tools/cisctl serve --listen 127.0.0.1:8080 --concurrency 2
+  go run ./tools/cisctl watch --interval 1h --slack
+  go run ./tools/cisctl watch --cron '0 */6 * * *' --hook 'cat > /tmp/cis_changes.json'
+  go run ./tools/cisctl notify slack --report reports/cisctl_report_20250101000000.json
 `)
 }
 
@@ -0,0 +1,175 @@
+package cisctl
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (a *App) runConfig(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl config show [--profile <name>] ...")
+		return 2
+	}
+
+	switch args[0] {
+	case "show":
+		return a.runConfigShow(ctx, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config command: %s\n", args[0])
+		return 2
+	}
+}
+
+// runConfigShow prints the effective configuration after profile, environment
+// and flag overrides, with secrets masked.
+func (a *App) runConfigShow(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl config show", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addCommonFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := common.loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := cfg.resolveToken(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	type sshView struct {
+		User         string `yaml:"user"`
+		UserFallback string `yaml:"user_fallback"`
+		KeyPath      string `yaml:"key_path"`
+		Port         int    `yaml:"port"`
+		Timeout      string `yaml:"timeout"`
+	}
+	type hostsView struct {
+		Source string   `yaml:"source"`
+		Groups []string `yaml:"groups"`
+	}
+	type spacesView struct {
+		Endpoint  string `yaml:"endpoint"`
+		Region    string `yaml:"region"`
+		AccessKey string `yaml:"access_key"`
+		SecretKey string `yaml:"secret_key"`
+	}
+	type notifyView struct {
+		SlackWebhookURL string   `yaml:"slack_webhook_url"`
+		RunURL          string   `yaml:"run_url"`
+		WebhookURLs     []string `yaml:"webhook_urls"`
+		WebhookSecret   string   `yaml:"webhook_secret"`
+	}
+	view := struct {
+		ConfigFile        string                       `yaml:"config_file"`
+		Profile           string                       `yaml:"profile"`
+		RootDir           string                       `yaml:"root_dir"`
+		EnvTag            string                       `yaml:"env_tag"`
+		Scope             *ReportScope                 `yaml:"scope"`
+		DOAccessToken     string                       `yaml:"do_access_token"`
+		DOTokenSource     string                       `yaml:"do_token_source"`
+		LogDir            string                       `yaml:"log_dir"`
+		LogLevel          string                       `yaml:"log_level"`
+		LogFormat         string                       `yaml:"log_format"`
+		ReportDir         string                       `yaml:"report_dir"`
+		SSH               sshView                      `yaml:"ssh"`
+		Hosts             hostsView                    `yaml:"hosts"`
+		Local             bool                         `yaml:"local"`
+		LocalTimeout      string                       `yaml:"local_timeout"`
+		Controls          []string                     `yaml:"controls"`
+		ControlParams     map[string]map[string]string `yaml:"control_params,omitempty"`
+		ControlsDir       string                       `yaml:"controls_dir"`
+		ExecControls      []string                     `yaml:"exec_controls"`
+		ExecTimeout       string                       `yaml:"exec_timeout"`
+		ExecSkipDefined   bool                         `yaml:"exec_skip_defined"`
+		RemediateFirewall string                       `yaml:"remediate_firewall"`
+		AlertEmails       []string                     `yaml:"alert_emails"`
+		WaiversFile       string                       `yaml:"waivers_file"`
+		Spaces            spacesView                   `yaml:"spaces"`
+		Notify            notifyView                   `yaml:"notify"`
+	}{
+		ConfigFile:    cfg.ConfigFile,
+		Profile:       cfg.Profile,
+		RootDir:       cfg.RootDir,
+		EnvTag:        cfg.EnvTag,
+		Scope:         cfg.reportScope(),
+		DOAccessToken: maskSecret(cfg.DOAccessToken),
+		DOTokenSource: cfg.TokenSource.String(),
+		LogDir:        cfg.LogDir,
+		LogLevel:      strings.ToLower(cfg.LogLevel.String()),
+		LogFormat:     cfg.LogFormat,
+		ReportDir:     cfg.ReportDir,
+		SSH: sshView{
+			User:         cfg.SSHUser,
+			UserFallback: cfg.SSHUserFallback,
+			KeyPath:      cfg.SSHKeyPath,
+			Port:         cfg.SSHPort,
+			Timeout:      cfg.SSHTimeout.String(),
+		},
+		Hosts: hostsView{
+			Source: firstNonEmpty(cfg.HostsSource, HostSourceDO),
+			Groups: cfg.HostGroups,
+		},
+		Local:             cfg.Local,
+		LocalTimeout:      cfg.LocalTimeout.String(),
+		Controls:          cfg.Controls,
+		ControlParams:     cfg.ControlParams,
+		ControlsDir:       cfg.ControlsDir,
+		ExecControls:      cfg.ExecControls,
+		ExecTimeout:       cfg.ExecTimeout.String(),
+		ExecSkipDefined:   cfg.ExecSkipDefined,
+		RemediateFirewall: cfg.RemediateFirewall,
+		AlertEmails:       cfg.AlertEmails,
+		WaiversFile:       cfg.WaiversFile,
+		Spaces: spacesView{
+			Endpoint:  cfg.SpacesEndpoint,
+			Region:    cfg.SpacesRegion,
+			AccessKey: maskSecret(cfg.SpacesAccessKey),
+			SecretKey: maskSecret(cfg.SpacesSecretKey),
+		},
+		Notify: notifyView{
+			SlackWebhookURL: maskURL(cfg.Notify.SlackWebhookURL),
+			RunURL:          cfg.Notify.RunURL,
+			WebhookSecret:   maskSecret(cfg.Notify.WebhookSecret),
+		},
+	}
+	for _, u := range cfg.Notify.WebhookURLs {
+		view.Notify.WebhookURLs = append(view.Notify.WebhookURLs, maskURL(u))
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(view); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print config: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// maskSecret keeps the last four characters of long secrets so operators can
+// tell which one is in use.
+func maskSecret(s string) string {
+	switch {
+	case s == "":
+		return ""
+	case len(s) < 16:
+		return "****"
+	default:
+		return "****" + s[len(s)-4:]
+	}
+}
+
+// maskURL keeps the scheme and host of a URL whose path may embed a token.
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	return redactURL(raw) + "/****"
+}
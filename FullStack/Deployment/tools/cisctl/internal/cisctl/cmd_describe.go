@@ -0,0 +1,124 @@
+package cisctl
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+func (a *App) runDescribe(args []string) int {
+	fs := flag.NewFlagSet("cisctl describe", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	common := addConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl describe [--controls-dir <dir>] <control-id>")
+		return 2
+	}
+	id := strings.TrimSpace(fs.Arg(0))
+
+	cfg, err := common.loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
+		return 2
+	}
+
+	var control Control
+	for _, c := range a.controls {
+		if c.ID() == id {
+			control = c
+			break
+		}
+	}
+	var planControl PlanControl
+	for _, c := range a.planControls {
+		if c.ID() == id {
+			planControl = c
+			break
+		}
+	}
+	docName, doc, hasDoc := ControlDoc(id)
+	advice, hasAdvice := RemediationAdvice(id)
+	if control == nil && planControl == nil && !hasDoc && !hasAdvice {
+		fmt.Fprintf(os.Stderr, "Unknown control: %s\n", id)
+		return 2
+	}
+
+	switch {
+	case control != nil:
+		fmt.Printf("%s  %s\n", control.ID(), control.Title())
+	case planControl != nil:
+		fmt.Printf("%s  %s (plan check only)\n", planControl.ID(), planControl.Title())
+	default:
+		fmt.Printf("%s  (documented only; not evaluated by cisctl)\n", id)
+	}
+
+	printSection("Documentation")
+	if hasDoc {
+		fmt.Printf("(%s)\n\n%s\n", docName, strings.TrimSpace(doc))
+	} else {
+		fmt.Println("No documentation page.")
+	}
+
+	printSection("Remediation")
+	if hasAdvice {
+		fmt.Println(advice)
+	} else {
+		fmt.Println("No remediation entry.")
+	}
+	if _, ok := control.(Remediator); ok {
+		fmt.Printf("Automated: cisctl remediate --controls %s\n", id)
+	}
+	if planControl != nil {
+		fmt.Printf("Before apply: cisctl scan-plan --controls %s <plan.json>\n", id)
+	}
+
+	d, ok := control.(Describer)
+	if !ok {
+		if d, ok = planControl.(Describer); !ok {
+			return 0
+		}
+	}
+	desc := d.Describe()
+	if desc.Source != "" || len(desc.Metadata) > 0 {
+		printSection("Definition")
+		if desc.Source != "" {
+			fmt.Printf("Source: %s\n", desc.Source)
+		}
+		for _, k := range slices.Sorted(maps.Keys(desc.Metadata)) {
+			fmt.Printf("%s: %s\n", k, desc.Metadata[k])
+		}
+	}
+	printSection("Resource types evaluated")
+	printList(desc.ResourceTypes)
+	printSection("API calls")
+	printList(desc.APICalls)
+	printSection("Shell commands (run over SSH on each droplet)")
+	printList(desc.Commands)
+	printSection("Evidence keys")
+	printList(desc.EvidenceKeys)
+	return 0
+}
+
+func printSection(title string) {
+	fmt.Printf("\n== %s ==\n", title)
+}
+
+func printList(items []string) {
+	if len(items) == 0 {
+		fmt.Println("None.")
+		return
+	}
+	for _, it := range items {
+		fmt.Printf("- %s\n", it)
+	}
+}
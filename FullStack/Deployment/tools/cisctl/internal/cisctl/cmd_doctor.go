@@ -0,0 +1,224 @@
+package cisctl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	doctorOK   = "OK"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+	doctorSkip = "SKIP"
+)
+
+type doctorCheck struct {
+	name   string
+	status string
+	detail string
+	fix    string
+}
+
+// runDoctor checks credentials, API access and SSH reachability before a run.
+func (a *App) runDoctor(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl doctor", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	common := addConfigFlags(fs)
+	flagTimeout := fs.Duration("timeout", 5*time.Second, "TCP probe timeout per host")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var checks []doctorCheck
+	add := func(c doctorCheck) {
+		checks = append(checks, c)
+		printDoctorCheck(c)
+	}
+
+	cfg, err := common.loadConfig(ctx)
+	if err != nil {
+		add(doctorCheck{
+			name:   "Configuration",
+			status: doctorFail,
+			detail: err.Error(),
+			fix:    "Set DO_ACCESS_TOKEN in the environment or in <root>/.env (see .env.example), or use --token-file, --token-command or --context.",
+		})
+		return doctorExit(checks)
+	}
+	add(doctorCheck{name: "Configuration", status: doctorOK, detail: fmt.Sprintf("root=%s env_tag=%s token=%s", cfg.RootDir, cfg.EnvTag, cfg.TokenSource)})
+
+	doClient, err := NewDOClient(cfg.DOAccessToken)
+	if err != nil {
+		add(doctorCheck{name: "DigitalOcean token", status: doctorFail, detail: err.Error(), fix: "Set DO_ACCESS_TOKEN."})
+		return doctorExit(checks)
+	}
+
+	account, err := doClient.GetAccount(ctx)
+	if err != nil {
+		add(doctorCheck{
+			name:   "DigitalOcean token",
+			status: doctorFail,
+			detail: err.Error(),
+			fix:    "Create a new token under API > Tokens in the DigitalOcean control panel and update DO_ACCESS_TOKEN.",
+		})
+		return doctorExit(checks)
+	}
+	add(accountCheck(account))
+
+	droplets, err := doClient.ListScopedDroplets(ctx, cfg.EnvTag, cfg.Scope)
+	switch {
+	case err != nil:
+		add(doctorCheck{name: "List droplets", status: doctorFail, detail: err.Error(), fix: "Grant the token read access to droplets."})
+	case len(droplets) == 0:
+		add(doctorCheck{
+			name:   "List droplets",
+			status: doctorWarn,
+			detail: fmt.Sprintf("no droplets for %s", cfg.ScopeString()),
+			fix:    "Check --env-tag / ENV_TAG and the scope selectors, or deploy the environment first (terraform apply).",
+		})
+	default:
+		add(doctorCheck{name: "List droplets", status: doctorOK, detail: fmt.Sprintf("%d droplet(s) for %s", len(droplets), cfg.ScopeString())})
+	}
+
+	if firewalls, err := doClient.ListFirewalls(ctx); err != nil {
+		add(doctorCheck{name: "List firewalls", status: doctorFail, detail: err.Error(), fix: "Grant the token read access to firewalls."})
+	} else {
+		add(doctorCheck{name: "List firewalls", status: doctorOK, detail: fmt.Sprintf("%d firewall(s) readable", len(firewalls))})
+	}
+
+	sshRunner, _ := NewSSHRunner(cfg)
+	fingerprint, err := sshRunner.Fingerprint()
+	if err != nil {
+		add(sshKeyFailure(cfg, err))
+	} else {
+		add(doctorCheck{name: "SSH key", status: doctorOK, detail: fmt.Sprintf("%s (%s)", sshRunner.KeyPath(), fingerprint)})
+		add(sshKeyRegisteredCheck(ctx, doClient, fingerprint))
+	}
+
+	hosts := make([]Host, 0, len(droplets))
+	for _, d := range droplets {
+		hosts = append(hosts, DropletHost(d))
+	}
+	if kind, _, _ := parseHostsSource(cfg.HostsSource); kind != HostSourceDO {
+		hosts = nil
+		src, err := NewHostSource(cfg, doClient)
+		if err == nil {
+			hosts, err = src.Hosts(ctx)
+		}
+		switch {
+		case err != nil:
+			add(doctorCheck{name: "List hosts", status: doctorFail, detail: err.Error(), fix: "Fix --hosts / HOSTS_SOURCE or the inventory file."})
+		case len(hosts) == 0:
+			add(doctorCheck{name: "List hosts", status: doctorWarn, detail: fmt.Sprintf("no hosts in %s", src), fix: "Check --host-groups / HOST_GROUPS and the inventory file."})
+		default:
+			add(doctorCheck{name: "List hosts", status: doctorOK, detail: fmt.Sprintf("%d host(s) in %s", len(hosts), src)})
+		}
+	}
+	for _, h := range hosts {
+		port := cfg.SSHPort
+		if h.Port > 0 {
+			port = h.Port
+		}
+		add(tcpProbeCheck(h, port, *flagTimeout))
+	}
+
+	return doctorExit(checks)
+}
+
+func accountCheck(account *godo.Account) doctorCheck {
+	c := doctorCheck{
+		name:   "DigitalOcean token",
+		status: doctorOK,
+		detail: fmt.Sprintf("account %s, status %s", account.Email, account.Status),
+	}
+	if account.Status != "active" {
+		c.status = doctorWarn
+		c.fix = "Resolve the account status shown in the DigitalOcean control panel (" + account.StatusMessage + ")."
+	}
+	return c
+}
+
+func sshKeyFailure(cfg Config, err error) doctorCheck {
+	c := doctorCheck{name: "SSH key", status: doctorFail, detail: err.Error()}
+	var passErr *ssh.PassphraseMissingError
+	switch {
+	case cfg.SSHKeyPath == "":
+		c.fix = "Set SSH_KEY_PATH to the private key whose public half is registered in DigitalOcean."
+	case errors.As(err, &passErr):
+		c.fix = "The key is passphrase-protected; use an unencrypted deploy key for cisctl."
+	case errors.Is(err, os.ErrNotExist):
+		c.fix = "SSH_KEY_PATH points to a missing file; fix the path (~ is expanded)."
+	default:
+		c.fix = "SSH_KEY_PATH must point to an OpenSSH or PEM private key."
+	}
+	return c
+}
+
+func sshKeyRegisteredCheck(ctx context.Context, doClient *DOClient, fingerprint string) doctorCheck {
+	keys, err := doClient.ListSSHKeys(ctx)
+	if err != nil {
+		return doctorCheck{name: "SSH key registered", status: doctorFail, detail: err.Error(), fix: "Grant the token read access to SSH keys."}
+	}
+	for _, k := range keys {
+		if k.Fingerprint == fingerprint {
+			return doctorCheck{name: "SSH key registered", status: doctorOK, detail: fmt.Sprintf("as %q", k.Name)}
+		}
+	}
+	return doctorCheck{
+		name:   "SSH key registered",
+		status: doctorWarn,
+		detail: fmt.Sprintf("fingerprint %s not found among %d account key(s)", fingerprint, len(keys)),
+		fix:    "Add the public key under Settings > Security and include it in TF_VAR_ssh_key_names, or use a key that is already registered.",
+	}
+}
+
+func tcpProbeCheck(h Host, port int, timeout time.Duration) doctorCheck {
+	name := fmt.Sprintf("TCP %d on %s", port, h.Name)
+	ip := h.Address
+	if ip == "" {
+		return doctorCheck{name: name, status: doctorSkip, detail: "no public IPv4 address"}
+	}
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return doctorCheck{
+			name:   name,
+			status: doctorFail,
+			detail: err.Error(),
+			fix:    "Allow inbound SSH from this machine in the droplet firewall (TF_VAR_admin_cidrs) and check SSH_PORT or the host's port.",
+		}
+	}
+	_ = conn.Close()
+	return doctorCheck{name: name, status: doctorOK, detail: addr}
+}
+
+func printDoctorCheck(c doctorCheck) {
+	fmt.Printf("[%-4s] %s", c.status, c.name)
+	if c.detail != "" {
+		fmt.Printf(": %s", c.detail)
+	}
+	fmt.Println()
+	if c.fix != "" {
+		fmt.Printf("       Fix: %s\n", c.fix)
+	}
+}
+
+func doctorExit(checks []doctorCheck) int {
+	for _, c := range checks {
+		if c.status == doctorFail {
+			fmt.Println("\nSome checks failed.")
+			return 1
+		}
+	}
+	fmt.Println("\nAll required checks passed.")
+	return 0
+}
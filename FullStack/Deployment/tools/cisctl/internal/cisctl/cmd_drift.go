@@ -0,0 +1,87 @@
+package cisctl
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runDrift compares a Terraform state with DigitalOcean, so changes made in
+// the console show up as failing findings.
+func (a *App) runDrift(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl drift", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addConfigFlags(fs)
+	common.waivers = fs.String("waivers", "", "Waivers YAML file (defaults WAIVERS_FILE or <root>/waivers.yaml if exists)")
+	var (
+		flagState = fs.String("state", "", "Terraform state: terraform.tfstate, `terraform state pull` or `terraform show -json` output")
+		flagJSON  = fs.Bool("json", false, "Print JSON report to stdout")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(*flagState) == "" || fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl drift --state <terraform.tfstate> [--env-tag <tag>] [--json]")
+		return 2
+	}
+
+	cfg, err := common.loadConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	st, err := LoadTerraformState(*flagState)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read state: %v\n", err)
+		return 2
+	}
+	doClient, err := NewDOClient(cfg.DOAccessToken)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init DO client: %v\n", err)
+		return 2
+	}
+	var waivers *WaiverSet
+	if cfg.WaiversFile != "" {
+		if waivers, err = LoadWaivers(cfg.WaiversFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load waivers: %v\n", err)
+			return 2
+		}
+	}
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create report dir: %v\n", err)
+		return 2
+	}
+
+	var out io.Writer = os.Stdout
+	if *flagJSON {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "State %s: %d resource(s); DigitalOcean scope: %s\n", st.Path, len(st.Resources), cfg.ScopeString())
+
+	startedAt := time.Now().UTC()
+	reportPath := filepath.Join(cfg.ReportDir, fmt.Sprintf("cisctl_drift_report_%s.json", startedAt.Format("20060102150405")))
+	report := NewReport(cfg, startedAt)
+
+	// Waiver tag selectors match the tags recorded in state.
+	tags := func(f Finding) []string {
+		for _, r := range st.Resources {
+			if r.String("id") == f.ResourceID {
+				return r.Strings("tags")
+			}
+		}
+		return nil
+	}
+	for _, result := range CheckDrift(ctx, cfg, doClient, st) {
+		waivers.Apply(&result, tags, result.FinishedAt)
+		fmt.Fprintf(out, "[%s] %s\n", result.ControlID, result.Title)
+		printResult(out, result, reportPath)
+		report.Results = append(report.Results, result)
+	}
+	return finishReport(report, reportPath, *flagJSON)
+}
@@ -0,0 +1,73 @@
+package cisctl
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func (a *App) runInventory(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl inventory", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addConfigFlags(fs)
+	var (
+		flagFormat     = fs.String("format", "table", "Output format: table, json or csv")
+		flagBlindSpots = fs.Bool("blind-spots", false, "Only list resources that no control evaluates")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *flagFormat != "table" && *flagFormat != "json" && *flagFormat != "csv" {
+		fmt.Fprintf(os.Stderr, "Invalid --format %q (want table, json or csv)\n", *flagFormat)
+		return 2
+	}
+
+	cfg, err := common.loadConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
+		return 2
+	}
+	doClient, err := NewDOClient(cfg.DOAccessToken)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init DO client: %v\n", err)
+		return 2
+	}
+
+	inv, err := BuildInventory(ctx, cfg, doClient, a.controls)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list droplets: %v\n", err)
+		return 1
+	}
+	if *flagBlindSpots {
+		kept := inv.Items[:0]
+		for _, it := range inv.Items {
+			if len(it.CoveredBy) == 0 {
+				kept = append(kept, it)
+			}
+		}
+		inv.Items = kept
+	}
+
+	switch *flagFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(inv)
+	case "csv":
+		err = WriteInventoryCSV(os.Stdout, inv)
+	default:
+		err = WriteInventoryTable(os.Stdout, inv)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write inventory: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,257 @@
+package cisctl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (a *App) runNotify(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl notify <slack|webhook> ...")
+		return 2
+	}
+
+	switch args[0] {
+	case "slack":
+		return a.runNotifySlack(ctx, args[1:])
+	case "webhook":
+		return a.runNotifyWebhook(ctx, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown notify command: %s\n", args[0])
+		return 2
+	}
+}
+
+// runNotifySlack replaces scripts/bash/slack_notify.sh. Like the script, it
+// exits 0 without sending when no webhook is configured.
+func (a *App) runNotifySlack(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl notify slack", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addConfigFlags(fs)
+	var (
+		flagReport      = fs.String("report", "", "Report file (default: latest cisctl_report_*.json in REPORT_DIR)")
+		flagWebhook     = fs.String("webhook-url", "", "Incoming webhook URL (defaults SLACK_WEBHOOK_URL)")
+		flagRunURL      = fs.String("run-url", "", "Link for the Open GitHub Run button (defaults RUN_URL)")
+		flagEnvName     = fs.String("env-name", "", "Environment shown in the message (defaults ENV_NAME or the report env tag)")
+		flagJobStatus   = fs.String("job-status", "", "CI job status: success, failure or cancelled (defaults JOB_STATUS or unknown, shown as a failure)")
+		flagRemediation = fs.String("remediation-map", "", "Control ID -> recommendation JSON (defaults REMEDIATION_MAP or the built-in map)")
+		flagRetries     = fs.Int("retries", 3, "Retries on rate limiting and server errors")
+		flagTimeout     = fs.Duration("timeout", 10*time.Second, "Timeout per request")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := common.loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+
+	webhookURL := firstNonEmpty(strings.TrimSpace(*flagWebhook), cfg.Notify.SlackWebhookURL)
+	if webhookURL == "" {
+		fmt.Println("SLACK_WEBHOOK_URL is not set. Skipping Slack notification.")
+		return 0
+	}
+
+	// Like the script, an unset job status is unknown and colored as danger.
+	opts := SlackOptions{
+		RunURL:             firstNonEmpty(*flagRunURL, cfg.Notify.RunURL),
+		JobStatus:          firstNonEmpty(*flagJobStatus, os.Getenv("JOB_STATUS"), "unknown"),
+		MaxPassLines:       envInt("MAX_PASS_LINES", 25),
+		MaxFailLines:       envInt("MAX_FAIL_LINES", 50),
+		MaxRecommendations: envInt("MAX_RECOMMENDATIONS", 15),
+	}
+	remediation, err := LoadRemediationMap(firstNonEmpty(*flagRemediation, os.Getenv("REMEDIATION_MAP")))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load remediation map: %v\n", err)
+		return 2
+	}
+	opts.Remediation = remediation
+
+	reportPath := strings.TrimSpace(*flagReport)
+	if reportPath == "" {
+		reportPath = latestReport(cfg.ReportDir)
+	}
+
+	var report *Report
+	if reportPath != "" {
+		r, err := ReadReport(reportPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Failed to read report: %v\n", err)
+			return 2
+		default:
+			report = &r
+		}
+	}
+	opts.EnvName = firstNonEmpty(*flagEnvName, os.Getenv("ENV_NAME"))
+	if opts.EnvName == "" && report != nil {
+		opts.EnvName = report.EnvTag
+	}
+	if opts.EnvName == "" {
+		opts.EnvName = "unknown"
+	}
+
+	client := &http.Client{Timeout: *flagTimeout}
+	if err := PostSlack(ctx, client, webhookURL, BuildSlackMessage(report, opts), *flagRetries); err != nil {
+		fmt.Fprintf(os.Stderr, "Slack notification failed: %v\n", err)
+		return 1
+	}
+	fmt.Println("Slack notification sent.")
+	return 0
+}
+
+// runNotifyWebhook posts the report, or one event per finding, as CloudEvents
+// to every configured URL.
+func (a *App) runNotifyWebhook(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl notify webhook", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addConfigFlags(fs)
+	var urls, headers stringsFlag
+	fs.Var(&urls, "url", "Webhook URL; repeatable (defaults WEBHOOK_URLS, comma-separated)")
+	fs.Var(&headers, "header", "Extra request header as 'Name: value'; repeatable")
+	var (
+		flagReport       = fs.String("report", "", "Report file (default: latest cisctl_report_*.json in REPORT_DIR)")
+		flagMode         = fs.String("mode", WebhookModeReport, "Send one event per report or per finding: report or finding")
+		flagSecret       = fs.String("secret", "", "HMAC-SHA256 signing secret (defaults WEBHOOK_SECRET)")
+		flagOnlyFailures = fs.Bool("only-failures", false, "Skip passing findings, and reports without failures")
+		flagBaseline     = fs.String("baseline", "", "Previous report; only send findings that changed since it")
+		flagRetries      = fs.Int("retries", 3, "Retries on network errors, rate limiting and server errors")
+		flagTimeout      = fs.Duration("timeout", 10*time.Second, "Timeout per request")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := common.loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+
+	if len(urls) == 0 {
+		urls = cfg.Notify.WebhookURLs
+	}
+	if len(urls) == 0 {
+		fmt.Fprintln(os.Stderr, "No webhook URL: pass --url or set WEBHOOK_URLS")
+		return 2
+	}
+	if *flagMode != WebhookModeReport && *flagMode != WebhookModeFinding {
+		fmt.Fprintf(os.Stderr, "Invalid --mode %q (want report or finding)\n", *flagMode)
+		return 2
+	}
+	header := http.Header{}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			fmt.Fprintf(os.Stderr, "Invalid --header %q (want 'Name: value')\n", h)
+			return 2
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	reportPath := strings.TrimSpace(*flagReport)
+	if reportPath == "" {
+		if reportPath = latestReport(cfg.ReportDir); reportPath == "" {
+			fmt.Fprintf(os.Stderr, "No cisctl_report_*.json in %s\n", cfg.ReportDir)
+			return 2
+		}
+	}
+	report, err := ReadReport(reportPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read report: %v\n", err)
+		return 2
+	}
+
+	opts := WebhookOptions{Mode: *flagMode, OnlyFailures: *flagOnlyFailures}
+	if p := strings.TrimSpace(*flagBaseline); p != "" {
+		baseline, err := ReadReport(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read baseline report: %v\n", err)
+			return 2
+		}
+		opts.Baseline = &baseline
+	}
+
+	events := BuildWebhookEvents(report, opts)
+	if len(events) == 0 {
+		fmt.Println("Nothing to send.")
+		return 0
+	}
+
+	secret := firstNonEmpty(*flagSecret, cfg.Notify.WebhookSecret)
+	client := &http.Client{Timeout: *flagTimeout}
+	failed := 0
+	for _, u := range urls {
+		ep := WebhookEndpoint{URL: u, Secret: secret, Headers: header}
+		sent := 0
+		for _, ev := range events {
+			if err := SendWebhookEvent(ctx, client, ep, ev, *flagRetries); err != nil {
+				fmt.Fprintf(os.Stderr, "Webhook %s: event %s (%s): %v\n", redactURL(u), ev.ID, ev.Type, err)
+				failed++
+				continue
+			}
+			sent++
+		}
+		fmt.Printf("Webhook %s: sent %d/%d event(s)\n", redactURL(u), sent, len(events))
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// stringsFlag collects the values of a repeatable flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// redactURL drops the path and query, which often embed a token.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<invalid url>"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// latestReport returns the newest cisctl_report_*.json in dir, or "".
+func latestReport(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "cisctl_report_*.json"))
+	var newest string
+	var newestMod time.Time
+	for _, m := range matches {
+		st, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if newest == "" || st.ModTime().After(newestMod) {
+			newest, newestMod = m, st.ModTime()
+		}
+	}
+	return newest
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,177 @@
+package cisctl
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+type plannedStep struct {
+	RemediationStep
+	controls []string
+}
+
+func (a *App) runRemediate(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl remediate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addCommonFlags(fs)
+	var (
+		flagApprove  = fs.Bool("approve", false, "Apply the plan (default: print it only)")
+		flagFirewall = fs.String("firewall", "", "Firewall name or ID to attach uncovered droplets to (defaults REMEDIATE_FIREWALL)")
+		flagTimeout  = fs.Duration("timeout", 10*time.Minute, "Maximum time to wait for each step to complete")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := common.loadRunConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
+		return 2
+	}
+	if v := strings.TrimSpace(*flagFirewall); v != "" {
+		cfg.RemediateFirewall = v
+	}
+
+	selected, err := a.selectControls(strings.Join(cfg.Controls, ","), cfg.Local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	var remediators []Remediator
+	for _, c := range selected {
+		if r, ok := c.(Remediator); ok {
+			remediators = append(remediators, r)
+		}
+	}
+	if len(remediators) == 0 {
+		fmt.Fprintln(os.Stderr, "None of the selected controls support remediation")
+		return 2
+	}
+
+	sess, err := newSession(cfg, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	defer sess.Close()
+	sess.log.Info("remediation started", "env_tag", cfg.EnvTag, "approve", *flagApprove)
+
+	var plan []plannedStep
+	for _, r := range remediators {
+		result := a.runOneControl(ctx, sess, r)
+		if result.Pass {
+			continue
+		}
+		steps, err := r.PlanRemediation(ctx, sess.deps(sess.log.With("control_id", r.ID())), result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to plan remediation for %s: %v\n", r.ID(), err)
+			return 2
+		}
+		plan = mergeSteps(plan, r.ID(), steps)
+	}
+
+	fmt.Println()
+	if len(plan) == 0 {
+		fmt.Println("Nothing to remediate.")
+		return 0
+	}
+	printPlan(plan)
+
+	automated := 0
+	for _, s := range plan {
+		if !s.Manual() {
+			automated++
+		}
+	}
+	if automated == 0 {
+		fmt.Println("\nNo automated steps; apply the manual steps above.")
+		return 1
+	}
+	if !*flagApprove {
+		fmt.Printf("\nDry run: re-run with --approve to apply %d step(s).\n", automated)
+		return 0
+	}
+
+	fmt.Println("\nApplying plan:")
+	failed := false
+	affected := map[string]bool{}
+	for _, s := range plan {
+		if s.Manual() {
+			continue
+		}
+		fmt.Printf("  %s: %s ... ", s.Resource, s.Action)
+		stepCtx, cancel := context.WithTimeout(ctx, *flagTimeout)
+		err := s.Apply(stepCtx)
+		cancel()
+		log := sess.log.With("step", s.Key, "controls", strings.Join(s.controls, ","))
+		if err != nil {
+			failed = true
+			fmt.Printf("FAILED: %v\n", err)
+			log.Error("remediation step failed", "error", err.Error())
+			continue
+		}
+		fmt.Println("OK")
+		log.Info("remediation step applied")
+		for _, id := range s.controls {
+			affected[id] = true
+		}
+	}
+
+	fmt.Println("\nVerifying:")
+	for _, r := range remediators {
+		if !affected[r.ID()] {
+			continue
+		}
+		if result := a.runOneControl(ctx, sess, r); !result.Pass {
+			failed = true
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// mergeSteps appends steps proposed by controlID, folding steps whose key is
+// already planned into the existing entry.
+func mergeSteps(plan []plannedStep, controlID string, steps []RemediationStep) []plannedStep {
+	for _, s := range steps {
+		i := slices.IndexFunc(plan, func(p plannedStep) bool { return s.Key != "" && p.Key == s.Key })
+		if i >= 0 {
+			if !slices.Contains(plan[i].controls, controlID) {
+				plan[i].controls = append(plan[i].controls, controlID)
+			}
+			continue
+		}
+		plan = append(plan, plannedStep{RemediationStep: s, controls: []string{controlID}})
+	}
+	return plan
+}
+
+func printPlan(plan []plannedStep) {
+	fmt.Println("Remediation plan:")
+	n := 0
+	for _, s := range plan {
+		if s.Manual() {
+			continue
+		}
+		n++
+		fmt.Printf("  %d. [%s] %s: %s\n", n, strings.Join(s.controls, ","), s.Resource, s.Action)
+	}
+	for _, s := range plan {
+		if s.Manual() {
+			fmt.Printf("  -  [%s] %s: MANUAL: %s\n", strings.Join(s.controls, ","), s.Resource, s.Action)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cisctl
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func (a *App) runReport(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl report <schema|validate|diff> ...")
+		return 2
+	}
+
+	switch args[0] {
+	case "schema":
+		_, _ = os.Stdout.Write(ReportSchema())
+		return 0
+	case "validate":
+		return a.runReportValidate(args[1:])
+	case "diff":
+		return a.runReportDiff(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown report command: %s\n", args[0])
+		return 2
+	}
+}
+
+func (a *App) runReportValidate(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl report validate <file>")
+		return 2
+	}
+	path := args[0]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read report: %v\n", err)
+		return 2
+	}
+
+	from, err := ValidateReport(data)
+	// Only a report that decoded and migrated reached the schema.
+	var verr *jsonschema.ValidationError
+	if from < ReportSchemaVersion && (err == nil || errors.As(err, &verr)) {
+		fmt.Printf("%s: schema_version %d, validated after migration to %d\n", path, from, ReportSchemaVersion)
+	}
+	if err != nil {
+		if verr != nil {
+			fmt.Printf("INVALID %s\n", path)
+			for _, cause := range flattenValidationError(verr) {
+				loc := cause.InstanceLocation
+				if loc == "" {
+					loc = "/"
+				}
+				fmt.Printf("  - %s: %s\n", loc, cause.Message)
+			}
+			return 1
+		}
+		fmt.Printf("INVALID %s: %v\n", path, err)
+		return 1
+	}
+
+	fmt.Printf("VALID %s (schema_version %d)\n", path, ReportSchemaVersion)
+	return 0
+}
+
+// runReportDiff exits 1 only when the new report has failures the old one did not.
+func (a *App) runReportDiff(args []string) int {
+	fs := flag.NewFlagSet("cisctl report diff", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	flagFormat := fs.String("format", "text", "Output format: text, json or markdown")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl report diff [--format text|json|markdown] <old.json> <new.json>")
+		return 2
+	}
+
+	var write func(io.Writer, ReportDiff) error
+	switch *flagFormat {
+	case "text":
+		write = WriteDiffText
+	case "json":
+		write = WriteDiffJSON
+	case "markdown", "md":
+		write = WriteDiffMarkdown
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *flagFormat)
+		return 2
+	}
+
+	oldReport, err := ReadReport(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read report: %v\n", err)
+		return 2
+	}
+	newReport, err := ReadReport(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read report: %v\n", err)
+		return 2
+	}
+
+	d := DiffReports(oldReport, newReport)
+	if err := write(os.Stdout, d); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write diff: %v\n", err)
+		return 2
+	}
+	if d.Summary.New > 0 {
+		return 1
+	}
+	return 0
+}
+
+// flattenValidationError returns the leaf errors, which carry the useful messages.
+func flattenValidationError(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var out []*jsonschema.ValidationError
+	for _, c := range err.Causes {
+		out = append(out, flattenValidationError(c)...)
+	}
+	return out
+}
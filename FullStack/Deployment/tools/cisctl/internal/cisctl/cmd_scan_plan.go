@@ -0,0 +1,133 @@
+package cisctl
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runScanPlan evaluates the plan controls against the planned state of a
+// Terraform plan, so misconfigurations fail before `terraform apply`. It
+// needs no DO token.
+func (a *App) runScanPlan(args []string) int {
+	fs := flag.NewFlagSet("cisctl scan-plan", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addConfigFlags(fs)
+	common.controls = fs.String("controls", "", "Comma-separated list of control IDs (default: all plan checks)")
+	common.waivers = fs.String("waivers", "", "Waivers YAML file (defaults WAIVERS_FILE or <root>/waivers.yaml if exists)")
+	flagJSON := fs.Bool("json", false, "Print JSON report to stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: cisctl scan-plan [--controls <ids>] [--waivers <file>] [--json] <plan.json>")
+		return 2
+	}
+
+	cfg, err := common.loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	controls, err := a.selectPlanControls(strings.Join(cfg.Controls, ","))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	plan, err := LoadTerraformPlan(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plan: %v\n", err)
+		return 2
+	}
+	var waivers *WaiverSet
+	if cfg.WaiversFile != "" {
+		if waivers, err = LoadWaivers(cfg.WaiversFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load waivers: %v\n", err)
+			return 2
+		}
+	}
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create report dir: %v\n", err)
+		return 2
+	}
+
+	// Progress goes to stderr when stdout carries the report.
+	var out io.Writer = os.Stdout
+	if *flagJSON {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "Plan %s: %s\n", plan.Path, planResourceCounts(plan))
+
+	startedAt := time.Now().UTC()
+	reportPath := filepath.Join(cfg.ReportDir, fmt.Sprintf("cisctl_plan_report_%s.json", startedAt.Format("20060102150405")))
+	report := NewReport(cfg, startedAt)
+	// A plan is not scoped by tags; it holds what Terraform manages.
+	report.Scope = nil
+	tags := func(f Finding) []string {
+		for _, r := range plan.Resources {
+			if r.Address == f.ResourceID {
+				return r.Strings("tags")
+			}
+		}
+		return nil
+	}
+	for _, c := range controls {
+		fmt.Fprintf(out, "[%s] %s ...\n", c.ID(), c.Title())
+		controlStart := time.Now().UTC()
+		outcome := c.ScanPlan(plan)
+		result := ControlResult{
+			ControlID:  c.ID(),
+			Title:      c.Title(),
+			StartedAt:  controlStart,
+			FinishedAt: time.Now().UTC(),
+			Notes:      outcome.Notes,
+			Findings:   outcome.Findings,
+		}
+		waivers.Apply(&result, tags, result.FinishedAt)
+		printResult(out, result, reportPath)
+		report.Results = append(report.Results, result)
+	}
+	return finishReport(report, reportPath, *flagJSON)
+}
+
+// selectPlanControls returns the plan controls named in a comma-separated ID
+// list, or all of them when the list is empty.
+func (a *App) selectPlanControls(ids string) ([]PlanControl, error) {
+	if strings.TrimSpace(ids) == "" {
+		return a.planControls, nil
+	}
+	var selected []PlanControl
+	for _, id := range splitList(ids) {
+		found := false
+		for _, c := range a.planControls {
+			if c.ID() == id {
+				selected = append(selected, c)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("control %s has no plan check", id)
+		}
+	}
+	return selected, nil
+}
+
+// planResourceCounts summarizes the planned resources, e.g.
+// "1 digitalocean_droplet, 1 digitalocean_firewall".
+func planResourceCounts(plan *TerraformPlan) string {
+	var parts []string
+	for _, typ := range PlanResourceTypes {
+		if n := len(plan.ResourcesOfType(typ)); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, typ))
+		}
+	}
+	if len(parts) == 0 {
+		return "no DigitalOcean resources"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,67 @@
+package cisctl
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func (a *App) runServe(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl serve", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addCommonFlags(fs)
+	var (
+		flagListen      = fs.String("listen", "127.0.0.1:8080", "Address to listen on")
+		flagConcurrency = fs.Int("concurrency", 2, "Maximum number of runs executing at once")
+		flagQueue       = fs.Int("queue-size", 100, "Maximum number of queued runs")
+		flagToken       = fs.String("token", "", "Require this bearer token (defaults CISCTL_API_TOKEN)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := common.loadRunConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
+		return 2
+	}
+	if *common.controls != "" {
+		fmt.Fprintln(os.Stderr, "--controls is chosen per run in the POST /runs body")
+		return 2
+	}
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create report dir: %v\n", err)
+		return 2
+	}
+
+	log := NewStreamLogger(os.Stderr, LogOptions{Format: cfg.LogFormat, Level: cfg.LogLevel})
+	srv, err := NewServer(a, cfg, log, ServerOptions{
+		Concurrency: *flagConcurrency,
+		QueueSize:   *flagQueue,
+		Token:       firstNonEmpty(*flagToken, os.Getenv("CISCTL_API_TOKEN")),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load runs: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv.Start(ctx)
+	log.Info("serving", "listen", *flagListen, "concurrency", *flagConcurrency, "report_dir", cfg.ReportDir)
+	if err := srv.ListenAndServe(ctx, *flagListen); err != nil {
+		log.Error("server stopped", "error", err.Error())
+		return 1
+	}
+	log.Info("server stopped")
+	return 0
+}
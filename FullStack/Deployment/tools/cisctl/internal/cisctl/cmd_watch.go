@@ -0,0 +1,264 @@
+package cisctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// watchNotifier delivers the changes found by one watch cycle.
+type watchNotifier struct {
+	client   *http.Client
+	slackURL string
+	slack    SlackOptions
+	webhooks []WebhookEndpoint
+	hook     string
+	retries  int
+	// evidence also reports findings whose only change is their evidence.
+	evidence bool
+}
+
+// runWatch runs the selected controls on a schedule and notifies only when
+// findings become failing or resolved compared with the previous run, or,
+// with --notify-evidence, when their evidence changes.
+func (a *App) runWatch(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cisctl watch", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	common := addCommonFlags(fs)
+	var webhooks stringsFlag
+	fs.Var(&webhooks, "webhook", "CloudEvents endpoint for changed findings; repeatable (defaults WEBHOOK_URLS or the profile)")
+	var (
+		flagInterval = fs.Duration("interval", 0, "Run every interval, starting immediately (e.g. 1h)")
+		flagCron     = fs.String("cron", "", "Run on a five-field cron schedule instead (e.g. '0 * * * *')")
+		flagUTC      = fs.Bool("utc", false, "Evaluate --cron in UTC instead of local time")
+		flagSlack    = fs.Bool("slack", false, "Post changes to the Slack webhook (SLACK_WEBHOOK_URL or the profile)")
+		flagSecret   = fs.String("secret", "", "HMAC-SHA256 secret for --webhook (defaults WEBHOOK_SECRET)")
+		flagHook     = fs.String("hook", "", "Shell command run on changes; the diff JSON is on stdin")
+		flagRetries  = fs.Int("retries", 3, "Retries per notification")
+		flagEvidence = fs.Bool("notify-evidence", false, "Also notify when only the evidence of a finding changed")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var sched Schedule
+	switch {
+	case *flagInterval > 0 && *flagCron != "":
+		fmt.Fprintln(os.Stderr, "Use either --interval or --cron, not both")
+		return 2
+	case *flagInterval > 0:
+		sched = IntervalSchedule{Every: *flagInterval}
+	case *flagCron != "":
+		loc := time.Local
+		if *flagUTC {
+			loc = time.UTC
+		}
+		cs, err := ParseCron(*flagCron, loc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 2
+		}
+		sched = cs
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: cisctl watch (--interval <duration> | --cron <expr>) [flags]")
+		return 2
+	}
+
+	cfg, err := common.loadRunConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return 2
+	}
+	if err := a.addControls(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load controls: %v\n", err)
+		return 2
+	}
+	selected, err := a.selectControls(strings.Join(cfg.Controls, ","), cfg.Local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	n := watchNotifier{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		hook:     strings.TrimSpace(*flagHook),
+		retries:  *flagRetries,
+		evidence: *flagEvidence,
+	}
+	if *flagSlack {
+		if n.slackURL = cfg.Notify.SlackWebhookURL; n.slackURL == "" {
+			fmt.Fprintln(os.Stderr, "--slack needs SLACK_WEBHOOK_URL or notify.slack_webhook_url in the profile")
+			return 2
+		}
+		n.slack = SlackOptions{
+			EnvName:      firstNonEmpty(os.Getenv("ENV_NAME"), cfg.EnvTag),
+			MaxFailLines: envInt("MAX_FAIL_LINES", 50),
+		}
+	}
+	secret := firstNonEmpty(*flagSecret, cfg.Notify.WebhookSecret)
+	if len(webhooks) == 0 {
+		webhooks = cfg.Notify.WebhookURLs
+	}
+	for _, u := range webhooks {
+		n.webhooks = append(n.webhooks, WebhookEndpoint{URL: u, Secret: secret})
+	}
+
+	log := NewStreamLogger(os.Stderr, LogOptions{Format: cfg.LogFormat, Level: cfg.LogLevel})
+
+	// The newest report on disk is the baseline, so a restart does not
+	// re-announce findings that were already reported. Only the results of
+	// the watched controls count, and a report of another scope is ignored.
+	var previous *Report
+	if p := latestReport(cfg.ReportDir); p != "" {
+		if r, err := ReadReport(p); err == nil {
+			if r.EnvTag == cfg.EnvTag && sameScope(r.Scope, cfg.reportScope()) {
+				r = onlyControls(r, selected)
+				previous = &r
+				log.Info("baseline loaded", "report", p)
+			} else {
+				log.Info("baseline ignored: scope differs", "report", p)
+			}
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	next := time.Now()
+	if _, ok := sched.(CronSchedule); ok {
+		next = sched.Next(next)
+	}
+	for {
+		if next.IsZero() {
+			fmt.Fprintln(os.Stderr, "Cron expression never matches")
+			return 2
+		}
+		log.Info("next run scheduled", "at", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			log.Info("watch stopped")
+			return 0
+		case <-time.After(time.Until(next)):
+		}
+
+		report, err := a.watchOnce(ctx, cfg, selected)
+		if err != nil {
+			log.Error("run failed", "error", err.Error())
+		} else {
+			if previous == nil {
+				log.Info("baseline recorded", "pass", report.Summary.Pass, "fail", report.Summary.Fail)
+			} else {
+				report = keepErrored(*previous, report)
+				diff := DiffReports(*previous, report)
+				log.Info("run compared", "new", diff.Summary.New, "resolved", diff.Summary.Resolved, "changed_evidence", diff.Summary.ChangedEvidence)
+				if !n.evidence {
+					diff = diff.withoutEvidenceChanges()
+				}
+				if diff.Summary.New+diff.Summary.Resolved+diff.Summary.ChangedEvidence > 0 {
+					if err := n.notify(ctx, *previous, report, diff); err != nil {
+						log.Error("notification failed", "error", err.Error())
+					}
+				}
+			}
+			previous = &report
+		}
+
+		next = sched.Next(time.Now())
+	}
+}
+
+// sameScope reports whether two reports checked the same resources.
+func sameScope(a *ReportScope, b *ReportScope) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// onlyControls keeps the results of r for the given controls.
+func onlyControls(r Report, controls []Control) Report {
+	ids := map[string]bool{}
+	for _, c := range controls {
+		ids[c.ID()] = true
+	}
+	var results []ControlResult
+	for _, res := range r.Results {
+		if ids[res.ControlID] {
+			results = append(results, res)
+		}
+	}
+	r.Results = results
+	return r
+}
+
+// keepErrored replaces the results of controls that errored in report with
+// their results in previous. A control that could not run says nothing about
+// its findings, which are neither resolved now nor new once it runs again.
+func keepErrored(previous Report, report Report) Report {
+	old := map[string]ControlResult{}
+	for _, res := range previous.Results {
+		old[res.ControlID] = res
+	}
+	results := make([]ControlResult, 0, len(report.Results))
+	for _, res := range report.Results {
+		if prev, ok := old[res.ControlID]; ok && res.Error != "" {
+			res = prev
+		}
+		results = append(results, res)
+	}
+	report.Results = results
+	return report
+}
+
+func (a *App) watchOnce(ctx context.Context, cfg Config, controls []Control) (Report, error) {
+	sess, err := newSession(cfg, "")
+	if err != nil {
+		return Report{}, err
+	}
+	defer sess.Close()
+	report, _, err := a.executeRun(ctx, sess, controls)
+	return report, err
+}
+
+func (n watchNotifier) notify(ctx context.Context, previous Report, report Report, diff ReportDiff) error {
+	var errs []error
+	if n.slackURL != "" {
+		if err := PostSlack(ctx, n.client, n.slackURL, BuildSlackDiffMessage(diff, n.slack), n.retries); err != nil {
+			errs = append(errs, fmt.Errorf("slack: %w", err))
+		}
+	}
+	if len(n.webhooks) > 0 {
+		events := BuildWebhookEvents(report, WebhookOptions{Mode: WebhookModeFinding, Baseline: &previous, SkipEvidence: !n.evidence})
+		for _, ep := range n.webhooks {
+			for _, ev := range events {
+				if err := SendWebhookEvent(ctx, n.client, ep, ev, n.retries); err != nil {
+					errs = append(errs, fmt.Errorf("webhook %s: %w", redactURL(ep.URL), err))
+				}
+			}
+		}
+	}
+	if n.hook != "" {
+		body, err := json.Marshal(diff)
+		if err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", n.hook)
+		cmd.Stdin = bytes.NewReader(body)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("hook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
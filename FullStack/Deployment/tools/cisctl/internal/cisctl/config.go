@@ -1,7 +1,11 @@
 package cisctl
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,72 +18,348 @@ type Config struct {
 	EnvTag  string
 
 	LogDir    string
+	LogLevel  slog.Level
+	LogFormat string
 	ReportDir string
 
 	DOAccessToken string
+	// TokenSource records where DOAccessToken came from.
+	TokenSource TokenSource
 
-	SSHUser        string
+	SSHUser         string
 	SSHUserFallback string
-	SSHKeyPath     string
-	SSHPort        int
-	SSHTimeout     time.Duration
+	SSHKeyPath      string
+	SSHPort         int
+	SSHTimeout      time.Duration
+
+	// RemediateFirewall is the firewall (name or ID) that remediation adds
+	// uncovered droplets to.
+	RemediateFirewall string
+	AlertEmails       []string
+
+	// WaiversFile lists accepted risks; empty means no waivers.
+	WaiversFile string
+
+	// Spaces credentials, used to list buckets over the S3 API.
+	SpacesEndpoint  string
+	SpacesRegion    string
+	SpacesAccessKey string
+	SpacesSecretKey string
+
+	// Profile and ConfigFile record where profile settings came from.
+	Profile    string
+	ConfigFile string
+
+	// Scope narrows the scan beyond EnvTag.
+	Scope Scope
+
+	// HostsSource selects the hosts the SSH-based controls check: "do" (the
+	// scoped droplets, the default), "ansible:<hosts.ini>" or
+	// "static:<hosts.yaml>". HostGroups limits inventory hosts to groups.
+	HostsSource string
+	HostGroups  []string
+	// Local runs only the host controls, on this machine instead of over
+	// SSH, and needs no DO token. LocalTimeout bounds each of its host
+	// commands; zero means no limit, like commands run over SSH.
+	Local        bool
+	LocalTimeout time.Duration
+
+	// Controls is the default control selection; empty means all.
+	Controls      []string
+	ControlParams map[string]map[string]string
+	// ControlsDir holds YAML host-check controls that run besides the
+	// built-in ones; empty means none.
+	ControlsDir string
+	// ExecControls are files, directories or globs of external control
+	// executables (see ExecProtocolVersion), relative to RootDir.
+	// ExecTimeout bounds each run. An exec control whose ID is taken is an
+	// error unless ExecSkipDefined is set, which skips it.
+	ExecControls    []string
+	ExecTimeout     time.Duration
+	ExecSkipDefined bool
+
+	Notify NotifyConfig
 }
 
-func LoadConfig(rootDir string, envTagFlag string) (Config, error) {
+// NotifyConfig holds the default notifier settings of the notify and watch
+// commands.
+type NotifyConfig struct {
+	SlackWebhookURL string
+	RunURL          string
+	WebhookURLs     []string
+	WebhookSecret   string
+}
+
+var errMissingToken = errors.New("missing DO access token (set DO_ACCESS_TOKEN, DO_TOKEN_FILE, DO_TOKEN_COMMAND or DOCTL_CONTEXT)")
+
+// LoadConfig builds the configuration from the built-in defaults, then the
+// selected profile (nil for none), then environment variables and finally the
+// env tag flag; see loadSettings. It reads the DO access token from its source
+// and fails when there is none, unless the run is local and needs no token.
+func LoadConfig(ctx context.Context, rootDir string, profile *Profile, envTagFlag string) (Config, error) {
+	cfg, err := loadSettings(rootDir, profile, envTagFlag, nil)
+	if err != nil {
+		return Config{}, err
+	}
+	// Local runs only check this machine.
+	if cfg.Local {
+		return cfg, nil
+	}
+	if err := cfg.resolveToken(ctx); err != nil {
+		return Config{}, err
+	}
+	if cfg.DOAccessToken == "" {
+		return Config{}, errMissingToken
+	}
+	return cfg, nil
+}
+
+// loadSettings is LoadConfig without the token requirement, for commands that
+// do not talk to DigitalOcean. The layers, from lowest to highest, are the
+// built-in defaults, the variables of dotenv (the keys loaded from the .env
+// file), the profile, the rest of the environment and the env tag flag.
+func loadSettings(rootDir string, profile *Profile, envTagFlag string, dotenv map[string]bool) (Config, error) {
 	cfg := Config{
-		RootDir:        rootDir,
-		EnvTag:         "env:demo",
-		LogDir:         filepath.Join(rootDir, "logs"),
-		ReportDir:      filepath.Join(rootDir, "reports"),
-		SSHUser:        "devops",
+		RootDir:         rootDir,
+		EnvTag:          "env:demo",
+		LogDir:          filepath.Join(rootDir, "logs"),
+		LogFormat:       LogFormatText,
+		ReportDir:       filepath.Join(rootDir, "reports"),
+		SSHUser:         "devops",
 		SSHUserFallback: "root",
-		SSHPort:        22,
-		SSHTimeout:     10 * time.Second,
+		SSHPort:         22,
+		SSHTimeout:      10 * time.Second,
+		SpacesRegion:    "sgp1",
+		ExecTimeout:     10 * time.Minute,
+	}
+	if p := filepath.Join(rootDir, "waivers.yaml"); fileExists(p) {
+		cfg.WaiversFile = p
+	}
+	if p := filepath.Join(rootDir, "controls.d"); fileExists(p) {
+		cfg.ControlsDir = p
+	}
+	// Values read from the .env file rank below the profile; variables of the
+	// real environment rank above it.
+	fromDotEnv := func(key string) string {
+		if dotenv[key] {
+			return os.Getenv(key)
+		}
+		return ""
+	}
+	fromShell := func(key string) string {
+		if dotenv[key] {
+			return ""
+		}
+		return os.Getenv(key)
+	}
+	if err := cfg.applyEnv(fromDotEnv); err != nil {
+		return Config{}, err
+	}
+	if profile != nil {
+		if err := profile.apply(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.applyEnv(fromShell); err != nil {
+		return Config{}, err
 	}
 
 	if v := strings.TrimSpace(envTagFlag); v != "" {
 		cfg.EnvTag = v
-	} else if v := strings.TrimSpace(os.Getenv("ENV_TAG")); v != "" {
+	}
+	// doctl's current context is the last resort when doctl is configured.
+	if cfg.DOAccessToken == "" && cfg.TokenSource.Kind == "" && fileExists(DoctlConfigPath()) {
+		cfg.TokenSource = TokenSource{Kind: TokenSourceDoctl}
+	}
+	cfg.SpacesEndpoint = firstNonEmpty(cfg.SpacesEndpoint, "https://"+cfg.SpacesRegion+".digitaloceanspaces.com")
+	if err := cfg.Scope.compile(); err != nil {
+		return Config{}, err
+	}
+	if _, _, err := parseHostsSource(cfg.HostsSource); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// applyEnv copies the settings that getenv returns over cfg.
+func (cfg *Config) applyEnv(getenv func(string) string) error {
+	env := func(key string) string { return strings.TrimSpace(getenv(key)) }
+
+	if v := env("ENV_TAG"); v != "" {
 		cfg.EnvTag = v
 	}
 
-	cfg.DOAccessToken = firstNonEmpty(
-		os.Getenv("DO_ACCESS_TOKEN"),
-		os.Getenv("DIGITALOCEAN_ACCESS_TOKEN"),
-		os.Getenv("TF_VAR_do_token"),
-	)
-	if cfg.DOAccessToken == "" {
-		return Config{}, errors.New("missing DO access token (set DO_ACCESS_TOKEN or DIGITALOCEAN_ACCESS_TOKEN)")
+	// A token wins over the token sources of the same layer; a source then
+	// replaces a token from a lower layer.
+	tokenSet := false
+	for _, name := range []string{"DO_ACCESS_TOKEN", "DIGITALOCEAN_ACCESS_TOKEN", "TF_VAR_do_token"} {
+		if v := env(name); v != "" {
+			cfg.DOAccessToken = v
+			cfg.TokenSource = TokenSource{Kind: TokenSourceEnv, Ref: name}
+			tokenSet = true
+			break
+		}
+	}
+	if !tokenSet {
+		if v := env("DO_TOKEN_FILE"); v != "" {
+			cfg.DOAccessToken = ""
+			cfg.TokenSource = TokenSource{Kind: TokenSourceFile, Ref: v}
+		} else if v := env("DO_TOKEN_COMMAND"); v != "" {
+			cfg.DOAccessToken = ""
+			cfg.TokenSource = TokenSource{Kind: TokenSourceCommand, Ref: v}
+		} else if v := env("DOCTL_CONTEXT"); v != "" {
+			cfg.DOAccessToken = ""
+			cfg.TokenSource = TokenSource{Kind: TokenSourceDoctl, Ref: v}
+		}
 	}
 
-	if v := strings.TrimSpace(os.Getenv("LOG_DIR")); v != "" {
+	if v := env("LOG_DIR"); v != "" {
 		cfg.LogDir = v
 	}
-	if v := strings.TrimSpace(os.Getenv("REPORT_DIR")); v != "" {
+	if v := env("LOG_LEVEL"); v != "" {
+		level, err := ParseLogLevel(v)
+		if err != nil {
+			return err
+		}
+		cfg.LogLevel = level
+	}
+	if v := env("LOG_FORMAT"); v != "" {
+		cfg.LogFormat = v
+	}
+	if v := env("REPORT_DIR"); v != "" {
 		cfg.ReportDir = v
 	}
 
-	if v := strings.TrimSpace(os.Getenv("SSH_USER")); v != "" {
+	if v := env("SSH_USER"); v != "" {
 		cfg.SSHUser = v
 	}
-	if v := strings.TrimSpace(os.Getenv("SSH_USER_FALLBACK")); v != "" {
+	if v := env("SSH_USER_FALLBACK"); v != "" {
 		cfg.SSHUserFallback = v
 	}
-	if v := strings.TrimSpace(os.Getenv("SSH_KEY_PATH")); v != "" {
+	if v := env("SSH_KEY_PATH"); v != "" {
 		cfg.SSHKeyPath = v
 	}
-	if v := strings.TrimSpace(os.Getenv("SSH_PORT")); v != "" {
+	if v := env("SSH_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil && port > 0 && port < 65536 {
 			cfg.SSHPort = port
 		}
 	}
-	if v := strings.TrimSpace(os.Getenv("SSH_TIMEOUT_SECONDS")); v != "" {
+	if v := env("SSH_TIMEOUT_SECONDS"); v != "" {
 		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
 			cfg.SSHTimeout = time.Duration(secs) * time.Second
 		}
 	}
 
-	return cfg, nil
+	if v := env("SCOPE_INCLUDE_TAGS"); v != "" {
+		cfg.Scope.IncludeTags = splitList(v)
+	}
+	if v := env("SCOPE_EXCLUDE_TAGS"); v != "" {
+		cfg.Scope.ExcludeTags = splitList(v)
+	}
+	if v := env("SCOPE_NAME_REGEX"); v != "" {
+		cfg.Scope.NameRegex = v
+	}
+	if v := env("SCOPE_REGIONS"); v != "" {
+		cfg.Scope.Regions = splitList(v)
+	}
+	if v := env("SCOPE_PROJECT"); v != "" {
+		cfg.Scope.Project = v
+	}
+
+	if v := env("HOSTS_SOURCE"); v != "" {
+		cfg.HostsSource = v
+	}
+	if v := env("HOST_GROUPS"); v != "" {
+		cfg.HostGroups = splitList(v)
+	}
+	if v := env("CISCTL_LOCAL"); v != "" {
+		local, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid CISCTL_LOCAL %q: %w", v, err)
+		}
+		cfg.Local = local
+	}
+
+	if v := env("CISCTL_CONTROLS"); v != "" {
+		cfg.Controls = splitList(v)
+	}
+	if v := env("CISCTL_CONTROLS_DIR"); v != "" {
+		cfg.ControlsDir = v
+	}
+	if v := env("CISCTL_EXEC_CONTROLS"); v != "" {
+		cfg.ExecControls = splitList(v)
+	}
+	if v := env("CISCTL_EXEC_SKIP_DEFINED"); v != "" {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid CISCTL_EXEC_SKIP_DEFINED %q: %w", v, err)
+		}
+		cfg.ExecSkipDefined = skip
+	}
+	if v := env("CISCTL_LOCAL_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid CISCTL_LOCAL_TIMEOUT %q (want a duration such as 30m, or 0 for no limit)", v)
+		}
+		cfg.LocalTimeout = d
+	}
+	if v := env("CISCTL_EXEC_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid CISCTL_EXEC_TIMEOUT %q (want a duration such as 10m)", v)
+		}
+		cfg.ExecTimeout = d
+	}
+
+	if v := env("REMEDIATE_FIREWALL"); v != "" {
+		cfg.RemediateFirewall = v
+	}
+	if v := env("ALERT_EMAILS_JSON"); v != "" {
+		if err := json.Unmarshal([]byte(v), &cfg.AlertEmails); err != nil {
+			return fmt.Errorf("invalid ALERT_EMAILS_JSON: %w", err)
+		}
+	}
+
+	if v := env("WAIVERS_FILE"); v != "" {
+		cfg.WaiversFile = v
+	}
+
+	cfg.SpacesRegion = firstNonEmpty(env("SPACES_REGION"), cfg.SpacesRegion)
+	cfg.SpacesEndpoint = firstNonEmpty(env("SPACES_ENDPOINT"), cfg.SpacesEndpoint)
+	cfg.SpacesAccessKey = firstNonEmpty(env("SPACES_ACCESS_KEY_ID"), env("AWS_ACCESS_KEY_ID"), cfg.SpacesAccessKey)
+	cfg.SpacesSecretKey = firstNonEmpty(env("SPACES_SECRET_ACCESS_KEY"), env("AWS_SECRET_ACCESS_KEY"), cfg.SpacesSecretKey)
+
+	cfg.Notify.SlackWebhookURL = firstNonEmpty(env("SLACK_WEBHOOK_URL"), cfg.Notify.SlackWebhookURL)
+	cfg.Notify.RunURL = firstNonEmpty(env("RUN_URL"), cfg.Notify.RunURL)
+	if v := env("WEBHOOK_URLS"); v != "" {
+		cfg.Notify.WebhookURLs = splitList(v)
+	}
+	cfg.Notify.WebhookSecret = firstNonEmpty(env("WEBHOOK_SECRET"), cfg.Notify.WebhookSecret)
+	return nil
+}
+
+// ControlParam returns a per-control parameter from the profile, or def.
+func (c Config) ControlParam(controlID string, key string, def string) string {
+	if v := strings.TrimSpace(c.ControlParams[controlID][key]); v != "" {
+		return v
+	}
+	return def
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 func firstNonEmpty(values ...string) string {
@@ -90,4 +370,3 @@ func firstNonEmpty(values ...string) string {
 	}
 	return ""
 }
-
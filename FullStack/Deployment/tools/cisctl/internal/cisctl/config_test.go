@@ -0,0 +1,70 @@
+package cisctl
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestConfigShowProfileOverridesDotEnv checks that a profile wins over the
+// .env file while the real environment still wins over the profile.
+func TestConfigShowProfileOverridesDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "ENV_TAG=env:demo\nSSH_PORT=22\nSSH_USER=dotenv-user\n")
+	writeFile(t, filepath.Join(dir, "cisctl.yaml"), `profiles:
+  prod:
+    env_tag: env:prod
+    ssh:
+      user: prod-user
+      port: 2222
+`)
+
+	for _, key := range []string{"ENV_TAG", "SSH_PORT", "SSH_USER", "CISCTL_CONFIG", "CISCTL_PROFILE", "DO_ACCESS_TOKEN"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("HOME", dir)
+	t.Setenv("SSH_USER", "shell-user")
+
+	out := runCapture(t, "config", "show", "--root", dir, "--profile", "prod")
+	for _, want := range []string{"env_tag: env:prod", "port: 2222", "user: shell-user"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("config show output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runCapture runs the app and returns what it printed on stdout.
+func runCapture(t *testing.T, args ...string) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+
+	code := NewApp(nil, nil, nil).Run(context.Background(), args)
+	os.Stdout = stdout
+	w.Close()
+	out := string(<-done)
+	if code != 0 {
+		t.Fatalf("cisctl %s: exit %d\n%s", strings.Join(args, " "), code, out)
+	}
+	return out
+}
@@ -16,7 +16,31 @@ type ControlOutcome struct {
 type Deps struct {
 	Config Config
 	DO     *DOClient
-	SSH    *SSHRunner
-	Log    *Logger
+	// Exec runs the commands of host controls: over SSH, or on this machine
+	// with --local.
+	Exec Executor
+	// HostSource lists the hosts of the host controls; nil means the scoped
+	// droplets.
+	HostSource HostSource
+	Log        *Logger
 }
 
+// HostControl is implemented by controls that only check hosts through
+// Deps.Exec. They are the controls that run with --local.
+type HostControl interface {
+	Control
+	HostControl()
+}
+
+// ControlLoader reads the controls defined in a directory, such as the YAML
+// host checks of Config.ControlsDir.
+type ControlLoader func(dir string) ([]Control, error)
+
+// PlanControl is implemented by checks that `cisctl scan-plan` evaluates
+// against the planned state of a Terraform plan before it is applied. Some
+// exist only as plan checks.
+type PlanControl interface {
+	ID() string
+	Title() string
+	ScanPlan(plan *TerraformPlan) ControlOutcome
+}
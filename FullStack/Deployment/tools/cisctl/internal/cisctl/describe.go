@@ -0,0 +1,66 @@
+package cisctl
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"cisctl/internal/assets"
+)
+
+// Describer is implemented by controls that document how they evaluate
+// resources, for `cisctl describe`.
+type Describer interface {
+	Describe() ControlDescription
+}
+
+type ControlDescription struct {
+	// ResourceTypes are the Finding.ResourceType values the control reports,
+	// which `cisctl inventory` uses to find resources no control evaluates.
+	ResourceTypes []string
+	// APICalls are the DigitalOcean API requests the control makes.
+	APICalls []string
+	// Commands are the shell commands run on each host over SSH.
+	Commands []string
+	// EvidenceKeys are the keys the control writes into Finding.Evidence.
+	EvidenceKeys []string
+	// Source is the file a control loaded at runtime is defined in, and
+	// Metadata its free-form fields such as severity or owner.
+	Source   string
+	Metadata map[string]string
+}
+
+// ControlDoc returns the embedded docs/controls page for a control ID, matched
+// by file names such as droplet_2.1.1_backups.md.
+func ControlDoc(controlID string) (name string, body string, ok bool) {
+	_ = fs.WalkDir(assets.ControlDocs, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || ok {
+			return err
+		}
+		base := path.Base(p)
+		if !strings.HasSuffix(base, ".md") {
+			return nil
+		}
+		if !strings.Contains(base, "_"+controlID+"_") && !strings.HasPrefix(base, controlID+"_") {
+			return nil
+		}
+		b, err := assets.ControlDocs.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		name, body, ok = path.Join("docs", p), string(b), true
+		return fs.SkipAll
+	})
+	return name, body, ok
+}
+
+// RemediationAdvice returns the scripts/slack/remediation.json entry for a
+// control ID.
+func RemediationAdvice(controlID string) (string, bool) {
+	m, err := LoadRemediationMap("")
+	if err != nil {
+		return "", false
+	}
+	v, ok := m[controlID]
+	return v, ok
+}
@@ -0,0 +1,309 @@
+package cisctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DiffNew             = "new"
+	DiffResolved        = "resolved"
+	DiffPersisting      = "persisting"
+	DiffChangedEvidence = "changed-evidence"
+)
+
+var diffStatusOrder = []string{DiffNew, DiffResolved, DiffChangedEvidence, DiffPersisting}
+
+type ReportDiff struct {
+	Old     DiffSide    `json:"old"`
+	New     DiffSide    `json:"new"`
+	Summary DiffSummary `json:"summary"`
+	Items   []DiffItem  `json:"items"`
+}
+
+type DiffSide struct {
+	RunID     string    `json:"run_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	EnvTag    string    `json:"env_tag"`
+}
+
+type DiffSummary struct {
+	New             int `json:"new"`
+	Resolved        int `json:"resolved"`
+	Persisting      int `json:"persisting"`
+	ChangedEvidence int `json:"changed_evidence"`
+}
+
+type DiffItem struct {
+	Status       string   `json:"status"`
+	ControlID    string   `json:"control_id"`
+	ResourceType string   `json:"resource_type"`
+	ResourceID   string   `json:"resource_id,omitempty"`
+	ResourceName string   `json:"resource_name,omitempty"`
+	Old          *Finding `json:"old,omitempty"`
+	New          *Finding `json:"new,omitempty"`
+}
+
+// DiffReports matches findings by control ID plus resource ID and classifies
+// how each one changed. Findings that pass in both reports with identical
+// evidence are left out.
+func DiffReports(oldReport Report, newReport Report) ReportDiff {
+	d := ReportDiff{
+		Old:   diffSide(oldReport),
+		New:   diffSide(newReport),
+		Items: []DiffItem{},
+	}
+
+	oldIdx, oldKeys := indexFindings(oldReport)
+	newIdx, newKeys := indexFindings(newReport)
+
+	for _, k := range newKeys {
+		nf := newIdx[k]
+		of, inOld := oldIdx[k]
+		var status string
+		switch {
+		case nf.Failing() && (!inOld || !of.Failing()):
+			status = DiffNew
+		case !nf.Failing() && inOld && of.Failing():
+			status = DiffResolved
+		case !inOld:
+			continue
+		case !sameEvidence(of, nf):
+			status = DiffChangedEvidence
+		case nf.Failing():
+			status = DiffPersisting
+		default:
+			continue
+		}
+		item := newDiffItem(status, k.controlID, nf)
+		item.New = &nf
+		if inOld {
+			item.Old = &of
+		}
+		d.Items = append(d.Items, item)
+	}
+	for _, k := range oldKeys {
+		of := oldIdx[k]
+		if _, inNew := newIdx[k]; inNew || !of.Failing() {
+			continue
+		}
+		item := newDiffItem(DiffResolved, k.controlID, of)
+		item.Old = &of
+		d.Items = append(d.Items, item)
+	}
+
+	slices.SortStableFunc(d.Items, func(a, b DiffItem) int {
+		if c := slices.Index(diffStatusOrder, a.Status) - slices.Index(diffStatusOrder, b.Status); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.ControlID, b.ControlID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ResourceName, b.ResourceName)
+	})
+
+	for _, it := range d.Items {
+		switch it.Status {
+		case DiffNew:
+			d.Summary.New++
+		case DiffResolved:
+			d.Summary.Resolved++
+		case DiffPersisting:
+			d.Summary.Persisting++
+		case DiffChangedEvidence:
+			d.Summary.ChangedEvidence++
+		}
+	}
+	return d
+}
+
+// withoutEvidenceChanges returns d without the findings whose only change is
+// their evidence.
+func (d ReportDiff) withoutEvidenceChanges() ReportDiff {
+	items := make([]DiffItem, 0, len(d.Items))
+	for _, it := range d.Items {
+		if it.Status != DiffChangedEvidence {
+			items = append(items, it)
+		}
+	}
+	d.Items = items
+	d.Summary.ChangedEvidence = 0
+	return d
+}
+
+type findingKey struct {
+	controlID string
+	resource  string
+}
+
+// indexFindings keys every finding of r. Findings without a resource ID fall
+// back to type and name; repeated keys within one control get a counter so
+// they still pair up in order between two reports.
+func indexFindings(r Report) (map[findingKey]Finding, []findingKey) {
+	idx := map[findingKey]Finding{}
+	var keys []findingKey
+	for _, res := range r.Results {
+		for _, f := range res.Findings {
+			resource := f.ResourceID
+			if resource == "" {
+				resource = f.ResourceType + "/" + f.ResourceName
+			}
+			k := findingKey{controlID: res.ControlID, resource: resource}
+			for n := 2; ; n++ {
+				if _, dup := idx[k]; !dup {
+					break
+				}
+				k.resource = fmt.Sprintf("%s#%d", resource, n)
+			}
+			idx[k] = f
+			keys = append(keys, k)
+		}
+	}
+	return idx, keys
+}
+
+func sameEvidence(a Finding, b Finding) bool {
+	return a.Reason == b.Reason && maps.Equal(a.Evidence, b.Evidence)
+}
+
+func newDiffItem(status string, controlID string, f Finding) DiffItem {
+	return DiffItem{
+		Status:       status,
+		ControlID:    controlID,
+		ResourceType: f.ResourceType,
+		ResourceID:   f.ResourceID,
+		ResourceName: f.ResourceName,
+	}
+}
+
+func diffSide(r Report) DiffSide {
+	return DiffSide{RunID: r.RunID, Timestamp: r.Timestamp, EnvTag: r.EnvTag}
+}
+
+func (it DiffItem) label() string {
+	name := it.ResourceName
+	if name == "" {
+		name = it.ResourceType
+	}
+	if it.ResourceID != "" {
+		name = fmt.Sprintf("%s (%s)", name, it.ResourceID)
+	}
+	return name
+}
+
+func (it DiffItem) reason() string {
+	switch {
+	case it.New != nil && it.Status != DiffResolved:
+		return it.New.Reason
+	case it.Old != nil:
+		return it.Old.Reason
+	}
+	return ""
+}
+
+func WriteDiffText(w io.Writer, d ReportDiff) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Diff %s -> %s\n", d.Old.Timestamp.Format(time.RFC3339), d.New.Timestamp.Format(time.RFC3339))
+	for _, status := range diffStatusOrder {
+		items := d.itemsWithStatus(status)
+		if len(items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s (%d)\n", strings.ToUpper(status), len(items))
+		for _, it := range items {
+			fmt.Fprintf(&b, "  [%s] %s", it.ControlID, it.label())
+			if r := it.reason(); r != "" {
+				fmt.Fprintf(&b, ": %s", r)
+			}
+			b.WriteString("\n")
+			if it.Status == DiffChangedEvidence && it.Old != nil && it.New != nil {
+				for _, line := range evidenceChanges(*it.Old, *it.New) {
+					fmt.Fprintf(&b, "      %s\n", line)
+				}
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\nSummary: new=%d resolved=%d persisting=%d changed-evidence=%d\n",
+		d.Summary.New, d.Summary.Resolved, d.Summary.Persisting, d.Summary.ChangedEvidence)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func WriteDiffMarkdown(w io.Writer, d ReportDiff) error {
+	var b strings.Builder
+	b.WriteString("## cisctl report diff\n\n")
+	fmt.Fprintf(&b, "`%s` → `%s`\n\n", d.Old.Timestamp.Format(time.RFC3339), d.New.Timestamp.Format(time.RFC3339))
+	b.WriteString("| New | Resolved | Persisting | Changed evidence |\n|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d |\n", d.Summary.New, d.Summary.Resolved, d.Summary.Persisting, d.Summary.ChangedEvidence)
+	for _, status := range diffStatusOrder {
+		items := d.itemsWithStatus(status)
+		if len(items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s (%d)\n\n", strings.ToUpper(status[:1])+strings.ReplaceAll(status[1:], "-", " "), len(items))
+		b.WriteString("| Control | Resource | Reason |\n|---|---|---|\n")
+		for _, it := range items {
+			reason := it.reason()
+			if it.Status == DiffChangedEvidence && it.Old != nil && it.New != nil {
+				reason = strings.Join(append([]string{reason}, evidenceChanges(*it.Old, *it.New)...), "<br>")
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", it.ControlID, markdownCell(it.label()), markdownCell(reason))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func WriteDiffJSON(w io.Writer, d ReportDiff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func (d ReportDiff) itemsWithStatus(status string) []DiffItem {
+	var out []DiffItem
+	for _, it := range d.Items {
+		if it.Status == status {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// evidenceChanges lists the reason and evidence keys that differ between a and b.
+func evidenceChanges(a Finding, b Finding) []string {
+	var out []string
+	if a.Reason != b.Reason {
+		out = append(out, fmt.Sprintf("reason: %q -> %q", a.Reason, b.Reason))
+	}
+	keys := slices.Sorted(maps.Keys(a.Evidence))
+	for k := range b.Evidence {
+		if _, ok := a.Evidence[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		av, aok := a.Evidence[k]
+		bv, bok := b.Evidence[k]
+		switch {
+		case !aok:
+			out = append(out, fmt.Sprintf("%s: (none) -> %q", k, bv))
+		case !bok:
+			out = append(out, fmt.Sprintf("%s: %q -> (none)", k, av))
+		case av != bv:
+			out = append(out, fmt.Sprintf("%s: %q -> %q", k, av, bv))
+		}
+	}
+	return out
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -3,11 +3,16 @@ package cisctl
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/digitalocean/godo"
 	"golang.org/x/oauth2"
 )
 
+const actionPollInterval = 5 * time.Second
+
 type DOClient struct {
 	c *godo.Client
 }
@@ -42,6 +47,15 @@ func (c *DOClient) ListDropletsByTag(ctx context.Context, tag string) ([]godo.Dr
 	return all, nil
 }
 
+// GetDroplet returns the droplet with id, or nil when it does not exist.
+func (c *DOClient) GetDroplet(ctx context.Context, id int) (*godo.Droplet, error) {
+	d, resp, err := c.c.Droplets.Get(ctx, id)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return d, err
+}
+
 func (c *DOClient) ListFirewalls(ctx context.Context) ([]godo.Firewall, error) {
 	var all []godo.Firewall
 	opt := &godo.ListOptions{PerPage: 200, Page: 1}
@@ -63,6 +77,211 @@ func (c *DOClient) ListFirewalls(ctx context.Context) ([]godo.Firewall, error) {
 	return all, nil
 }
 
+func (c *DOClient) GetAccount(ctx context.Context) (*godo.Account, error) {
+	account, _, err := c.c.Account.Get(ctx)
+	return account, err
+}
+
+func (c *DOClient) ListSSHKeys(ctx context.Context) ([]godo.Key, error) {
+	var all []godo.Key
+	opt := &godo.ListOptions{PerPage: 200, Page: 1}
+	for {
+		keys, resp, err := c.c.Keys.List(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, keys...)
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			break
+		}
+		opt.Page = page + 1
+	}
+	return all, nil
+}
+
+func (c *DOClient) ListAlertPolicies(ctx context.Context) ([]godo.AlertPolicy, error) {
+	var all []godo.AlertPolicy
+	opt := &godo.ListOptions{PerPage: 200, Page: 1}
+	for {
+		policies, resp, err := c.c.Monitoring.ListAlertPolicies(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, policies...)
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			break
+		}
+		opt.Page = page + 1
+	}
+	return all, nil
+}
+
+func (c *DOClient) ListVolumes(ctx context.Context) ([]godo.Volume, error) {
+	var all []godo.Volume
+	opt := &godo.ListOptions{PerPage: 200, Page: 1}
+	for {
+		volumes, resp, err := c.c.Storage.ListVolumes(ctx, &godo.ListVolumeParams{ListOptions: opt})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, volumes...)
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			break
+		}
+		opt.Page = page + 1
+	}
+	return all, nil
+}
+
+func (c *DOClient) ListVPCs(ctx context.Context) ([]*godo.VPC, error) {
+	var all []*godo.VPC
+	opt := &godo.ListOptions{PerPage: 200, Page: 1}
+	for {
+		vpcs, resp, err := c.c.VPCs.List(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, vpcs...)
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			break
+		}
+		opt.Page = page + 1
+	}
+	return all, nil
+}
+
+// ListProjectResources returns the URNs (e.g. do:droplet:123) of the resources
+// assigned to the project whose ID or name equals nameOrID.
+func (c *DOClient) ListProjectResources(ctx context.Context, nameOrID string) ([]string, error) {
+	var projectID string
+	opt := &godo.ListOptions{PerPage: 200, Page: 1}
+	for projectID == "" {
+		projects, resp, err := c.c.Projects.List(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			if p.ID == nameOrID || p.Name == nameOrID {
+				projectID = p.ID
+				break
+			}
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			break
+		}
+		opt.Page = page + 1
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("project %q not found", nameOrID)
+	}
+
+	var urns []string
+	opt = &godo.ListOptions{PerPage: 200, Page: 1}
+	for {
+		resources, resp, err := c.c.Projects.ListResources(ctx, projectID, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			urns = append(urns, r.URN)
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			break
+		}
+		opt.Page = page + 1
+	}
+	return urns, nil
+}
+
+// FindFirewall returns the firewall whose ID or name equals nameOrID.
+func (c *DOClient) FindFirewall(ctx context.Context, nameOrID string) (godo.Firewall, error) {
+	firewalls, err := c.ListFirewalls(ctx)
+	if err != nil {
+		return godo.Firewall{}, err
+	}
+	for _, fw := range firewalls {
+		if fw.ID == nameOrID || fw.Name == nameOrID {
+			return fw, nil
+		}
+	}
+	return godo.Firewall{}, fmt.Errorf("firewall %q not found", nameOrID)
+}
+
+func (c *DOClient) AddFirewallTags(ctx context.Context, firewallID string, tags ...string) error {
+	_, err := c.c.Firewalls.AddTags(ctx, firewallID, tags...)
+	return err
+}
+
+func (c *DOClient) AddFirewallDroplets(ctx context.Context, firewallID string, dropletIDs ...int) error {
+	_, err := c.c.Firewalls.AddDroplets(ctx, firewallID, dropletIDs...)
+	return err
+}
+
+// EnableDropletBackups starts the enable_backups action and waits for it to finish.
+func (c *DOClient) EnableDropletBackups(ctx context.Context, dropletID int) error {
+	action, _, err := c.c.DropletActions.EnableBackups(ctx, dropletID)
+	if err != nil {
+		return err
+	}
+	return c.WaitForAction(ctx, action)
+}
+
+func (c *DOClient) CreateAlertPolicy(ctx context.Context, req *godo.AlertPolicyCreateRequest) (*godo.AlertPolicy, error) {
+	policy, _, err := c.c.Monitoring.CreateAlertPolicy(ctx, req)
+	return policy, err
+}
+
+// WaitForAction polls an action until it completes, errors or ctx is done.
+func (c *DOClient) WaitForAction(ctx context.Context, action *godo.Action) error {
+	if action == nil {
+		return nil
+	}
+	ticker := time.NewTicker(actionPollInterval)
+	defer ticker.Stop()
+	for {
+		switch action.Status {
+		case godo.ActionCompleted:
+			return nil
+		case "errored":
+			return fmt.Errorf("action %d (%s) errored", action.ID, action.Type)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for action %d (%s): %w", action.ID, action.Type, ctx.Err())
+		case <-ticker.C:
+		}
+		next, _, err := c.c.Actions.Get(ctx, action.ID)
+		if err != nil {
+			return err
+		}
+		action = next
+	}
+}
+
 func HasFeature(d godo.Droplet, feature string) bool {
 	for _, f := range d.Features {
 		if f == feature {
@@ -1,41 +1,440 @@
 package cisctl
 
 import (
-	"bufio"
+	"fmt"
 	"os"
 	"strings"
 )
 
-// LoadDotEnv loads KEY=VALUE pairs from a .env file into process environment.
-// It is intentionally minimal and supports simple quoted/unquoted values.
-func LoadDotEnv(path string) error {
+// DotEnvVar is one assignment read from a .env file.
+type DotEnvVar struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// LoadDotEnv loads a .env file into the process environment with the same
+// values `set -a; . ./.env` gives in bash and returns the variables it set.
+// Variables that are already set keep their value unless override is true.
+func LoadDotEnv(path string, override bool) ([]DotEnvVar, error) {
 	if path == "" {
-		return nil
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-	f, err := os.Open(path)
+	vars, err := ParseDotEnv(path, string(data), override, os.LookupEnv)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for _, v := range vars {
+		if err := os.Setenv(v.Key, v.Value); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, v.Line, err)
+		}
 	}
-	defer f.Close()
+	return vars, nil
+}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+// ParseDotEnv parses .env source as a bash script made only of variable
+// assignments:
+//
+//   - blank lines, `# comments` and an optional `export` prefix;
+//   - several assignments per line (`A=1 B=2`) and `;` separators;
+//   - unquoted, 'single-quoted', "double-quoted" and $'ANSI-C' values, which
+//     may be concatenated and may span lines;
+//   - backslash escapes and line continuations;
+//   - $VAR, ${VAR}, ${VAR:-default} and ${VAR-default} expansion, from earlier
+//     assignments first and then env;
+//   - a leading ~ expands to $HOME.
+//
+// Anything bash would run as a command, including command substitution, is
+// an error reported with its line number. Variables that env already has are
+// left out of the result unless override is true.
+func ParseDotEnv(path string, src string, override bool, env func(string) (string, bool)) ([]DotEnvVar, error) {
+	p := &dotenvParser{path: path, src: src, line: 1, vars: map[string]string{}, env: env}
+	var out []DotEnvVar
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			return out, nil
 		}
-		key, val, ok := strings.Cut(line, "=")
-		if !ok {
+		switch p.peek() {
+		case '\n', ';':
+			p.next()
+			continue
+		case '#':
+			p.skipComment()
 			continue
 		}
-		key = strings.TrimSpace(key)
-		val = strings.TrimSpace(val)
-		if key == "" {
+
+		exported := false
+		start, startLine := p.pos, p.line
+		if p.readName() == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+			exported = true
+			p.skipBlanks()
+		} else {
+			p.pos, p.line = start, startLine
+		}
+
+		for {
+			line := p.line
+			word := p.pos
+			name := p.readName()
+			if p.peek() != '=' {
+				if exported && validEnvName(name) && p.atWordEnd() {
+					// `export NAME` only marks an existing variable.
+				} else {
+					return nil, p.errorf("expected KEY=VALUE, got %q (commands are not supported)", p.restOfWord(word))
+				}
+			} else {
+				if !validEnvName(name) {
+					return nil, p.errorf("invalid variable name %q", name)
+				}
+				p.next()
+				value, err := p.readValue()
+				if err != nil {
+					return nil, err
+				}
+				if _, set := env(name); !set || override {
+					p.vars[name] = value
+					out = append(out, DotEnvVar{Key: name, Value: value, Line: line})
+				}
+			}
+
+			p.skipBlanks()
+			if p.eof() || p.peek() == '\n' || p.peek() == ';' {
+				break
+			}
+			if p.peek() == '#' {
+				p.skipComment()
+				break
+			}
+		}
+	}
+}
+
+type dotenvParser struct {
+	path string
+	src  string
+	pos  int
+	line int
+	vars map[string]string
+	env  func(string) (string, bool)
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.path, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *dotenvParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *dotenvParser) skipBlanks() {
+	for p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r' {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) atWordEnd() bool {
+	switch p.peek() {
+	case 0, ' ', '\t', '\r', '\n', ';':
+		return true
+	}
+	return false
+}
+
+func (p *dotenvParser) restOfWord(start int) string {
+	end := start
+	for end < len(p.src) && !strings.ContainsRune(" \t\r\n;", rune(p.src[end])) {
+		end++
+	}
+	return p.src[start:end]
+}
+
+func (p *dotenvParser) readName() string {
+	start := p.pos
+	for !p.eof() && isNameChar(p.peek()) {
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *dotenvParser) lookup(name string) string {
+	if v, ok := p.vars[name]; ok {
+		return v
+	}
+	v, _ := p.env(name)
+	return v
+}
+
+// readValue reads one shell word after `KEY=`.
+func (p *dotenvParser) readValue() (string, error) {
+	var b strings.Builder
+	if p.peek() == '~' && (p.pos+1 == len(p.src) || p.src[p.pos+1] == '/' || strings.ContainsRune(" \t\r\n;", rune(p.src[p.pos+1]))) {
+		p.next()
+		b.WriteString(p.lookup("HOME"))
+	}
+	for !p.atWordEnd() {
+		switch c := p.peek(); c {
+		case '\\':
+			p.next()
+			if p.eof() {
+				b.WriteByte('\\')
+			} else if d := p.next(); d != '\n' {
+				b.WriteByte(d)
+			}
+		case '\'':
+			if err := p.readSingleQuoted(&b); err != nil {
+				return "", err
+			}
+		case '"':
+			if err := p.readDoubleQuoted(&b); err != nil {
+				return "", err
+			}
+		case '$':
+			if err := p.readDollar(&b, false); err != nil {
+				return "", err
+			}
+		case '`':
+			return "", p.errorf("command substitution is not supported")
+		case '|', '&', '<', '>', '(', ')':
+			return "", p.errorf("unquoted %q in value; quote the value", c)
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+	return b.String(), nil
+}
+
+func (p *dotenvParser) readSingleQuoted(b *strings.Builder) error {
+	openLine := p.line
+	p.next()
+	for p.peek() != '\'' {
+		if p.eof() {
+			p.line = openLine
+			return p.errorf("unterminated single quote")
+		}
+		b.WriteByte(p.next())
+	}
+	p.next()
+	return nil
+}
+
+func (p *dotenvParser) readDoubleQuoted(b *strings.Builder) error {
+	openLine := p.line
+	p.next()
+	for {
+		if p.eof() {
+			p.line = openLine
+			return p.errorf("unterminated double quote")
+		}
+		switch c := p.peek(); c {
+		case '"':
+			p.next()
+			return nil
+		case '\\':
+			p.next()
+			switch d := p.peek(); d {
+			case '$', '`', '"', '\\':
+				b.WriteByte(p.next())
+			case '\n':
+				p.next()
+			default:
+				b.WriteByte('\\')
+			}
+		case '$':
+			if err := p.readDollar(b, true); err != nil {
+				return err
+			}
+		case '`':
+			return p.errorf("command substitution is not supported")
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+}
+
+func (p *dotenvParser) readDollar(b *strings.Builder, quoted bool) error {
+	p.next()
+	switch c := p.peek(); {
+	case c == '{':
+		p.next()
+		name := p.readName()
+		if !validEnvName(name) {
+			return p.errorf("bad substitution ${%s", name)
+		}
+		value, set := p.lookup(name), p.isSet(name)
+		var useDefault bool
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ":-"):
+			p.next()
+			p.next()
+			useDefault = value == ""
+		case p.peek() == '-':
+			p.next()
+			useDefault = !set
+		case p.peek() == '}':
+			p.next()
+			b.WriteString(value)
+			return nil
+		default:
+			return p.errorf("unsupported substitution ${%s%c...}", name, p.peek())
+		}
+		def, err := p.readDefault(name, quoted)
+		if err != nil {
+			return err
+		}
+		if useDefault {
+			value = def
+		}
+		b.WriteString(value)
+	case c == '(':
+		return p.errorf("command substitution is not supported")
+	case c == '\'' && !quoted:
+		return p.readANSIC(b)
+	case c >= '0' && c <= '9':
+		// Positional parameters are empty when sourcing a file.
+		p.next()
+	case isNameChar(c):
+		b.WriteString(p.lookup(p.readName()))
+	case c == '"' && !quoted:
+		// $"..." is a translatable string; without a catalog it is "...".
+	default:
+		b.WriteByte('$')
+	}
+	return nil
+}
+
+// readDefault reads the default word of ${name:-word} up to its closing
+// brace, with the expansions and quote removal bash applies to it. Inside
+// double quotes, single quotes are literal and backslash escapes as in
+// double quotes.
+func (p *dotenvParser) readDefault(name string, quoted bool) (string, error) {
+	openLine := p.line
+	var b strings.Builder
+	if p.peek() == '~' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '/' || p.src[p.pos+1] == '}') && !quoted {
+		p.next()
+		b.WriteString(p.lookup("HOME"))
+	}
+	for {
+		if p.eof() {
+			p.line = openLine
+			return "", p.errorf("unterminated ${%s", name)
+		}
+		switch c := p.peek(); {
+		case c == '}':
+			p.next()
+			return b.String(), nil
+		case c == '\\':
+			p.next()
+			switch {
+			case p.eof():
+				b.WriteByte('\\')
+			case p.peek() == '\n':
+				p.next()
+			case !quoted || strings.IndexByte("$`\"\\}", p.peek()) >= 0:
+				b.WriteByte(p.next())
+			default:
+				b.WriteByte('\\')
+			}
+		case c == '\'' && !quoted:
+			if err := p.readSingleQuoted(&b); err != nil {
+				return "", err
+			}
+		case c == '"':
+			if err := p.readDoubleQuoted(&b); err != nil {
+				return "", err
+			}
+		case c == '$':
+			if err := p.readDollar(&b, quoted); err != nil {
+				return "", err
+			}
+		case c == '`':
+			return "", p.errorf("command substitution is not supported")
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+}
+
+func (p *dotenvParser) isSet(name string) bool {
+	if _, ok := p.vars[name]; ok {
+		return true
+	}
+	_, ok := p.env(name)
+	return ok
+}
+
+// readANSIC reads a $'...' string.
+func (p *dotenvParser) readANSIC(b *strings.Builder) error {
+	openLine := p.line
+	p.next()
+	for {
+		if p.eof() {
+			p.line = openLine
+			return p.errorf("unterminated $' quote")
+		}
+		c := p.next()
+		if c == '\'' {
+			return nil
+		}
+		if c != '\\' || p.eof() {
+			b.WriteByte(c)
 			continue
 		}
-		val = strings.Trim(val, `"'`)
-		_ = os.Setenv(key, val)
+		d := p.next()
+		switch d {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'e', 'E':
+			b.WriteByte(0x1b)
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			b.WriteByte(d)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(d)
+		}
 	}
-	return scanner.Err()
 }
 
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func validEnvName(name string) bool {
+	return name != "" && !(name[0] >= '0' && name[0] <= '9')
+}
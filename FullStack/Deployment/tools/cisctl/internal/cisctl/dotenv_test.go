@@ -0,0 +1,151 @@
+package cisctl
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// dotenvTestEnv is the environment the parser and bash see in the tests.
+var dotenvTestEnv = map[string]string{"HOME": "/home/u", "E": "envval", "EMPTY": ""}
+
+func dotenvLookup(key string) (string, bool) {
+	v, ok := dotenvTestEnv[key]
+	return v, ok
+}
+
+func TestParseDotEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{"plain", "A=1\nB=two\n", map[string]string{"A": "1", "B": "two"}},
+		{"comments", "# comment\n\nA=1 # trailing\n  B=2\n", map[string]string{"A": "1", "B": "2"}},
+		{"export", "export A=1\nexport B=2 C=3\nexport A\n", map[string]string{"A": "1", "B": "2", "C": "3"}},
+		{"several per line", "A=1 B=2; C=3\n", map[string]string{"A": "1", "B": "2", "C": "3"}},
+		{"empty", "A=\nB=''\nC=\"\"\n", map[string]string{"A": "", "B": "", "C": ""}},
+		{"single quotes", "A='a b $E \\n \"q\"'\n", map[string]string{"A": `a b $E \n "q"`}},
+		{"double quotes", "X=1\nA=\"x $X ${X}y \\\"q\\\" \\$ \\a 'b'\"\n", map[string]string{"X": "1", "A": `x 1 1y "q" $ \a 'b'`}},
+		{"ansi-c", "A=$'a\\tb\\nc\\'d\\\\'\n", map[string]string{"A": "a\tb\nc'd\\"}},
+		{"concatenation", "A=a'b c'\"d $E\"$'\\t'\n", map[string]string{"A": "ab cd envval\t"}},
+		{"unquoted escapes", "A=a\\ b\\$c\\\\\n", map[string]string{"A": `a b$c\`}},
+		{"continuation", "A=a\\\nb\nB=\"l1\nl2\"\nC=\"x\\\ny\"\n", map[string]string{"A": "ab", "B": "l1\nl2", "C": "xy"}},
+		{"env expansion", "A=$E/${E}\nB=$MISSING.\n", map[string]string{"A": "envval/envval", "B": "."}},
+		{"earlier assignment", "E=mine\nA=$E\n", map[string]string{"E": "mine", "A": "mine"}},
+		{"positional", "A=$1x\n", map[string]string{"A": "x"}},
+		{"default quoted", "A=${UNSET:-\"a b\"}\nB=${UNSET:-'c d'}\n", map[string]string{"A": "a b", "B": "c d"}},
+		{"default expands", "X=v\nA=${UNSET:-$X/${E}}\nB=${UNSET:-${ALSO:-in}ner}\n", map[string]string{"X": "v", "A": "v/envval", "B": "inner"}},
+		{"default escapes", "A=${UNSET:-a\\}b\\ c}\nB=\"${UNSET:-\\}\\x}\"\n", map[string]string{"A": "a}b c", "B": `}\x`}},
+		{"default inside double quotes", "A=\"${UNSET:-'x'}\"\nB=\"${UNSET:-\"y z\"}\"\n", map[string]string{"A": "'x'", "B": "y z"}},
+		{"default unused", "A=${E:-\"no\"}\n", map[string]string{"A": "envval"}},
+		{"default colon", "A=${EMPTY:-d}\nB=${EMPTY-d}\nC=${UNSET-d}\n", map[string]string{"A": "d", "B": "", "C": "d"}},
+		{"tilde", "A=~/x\nB=~\nC=a~\nD=${UNSET:-~/y}\n", map[string]string{"A": "/home/u/x", "B": "/home/u", "C": "a~", "D": "/home/u/y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := ParseDotEnv("test.env", tt.src, true, dotenvLookup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, v := range vars {
+				got[v.Key] = v.Value
+			}
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("%s = %q, want %q", k, got[k], want)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			checkBash(t, tt.src, tt.want)
+		})
+	}
+}
+
+// checkBash sources src with `set -a` in bash, when it is installed, and
+// compares the variables with want.
+func checkBash(t *testing.T, src string, want map[string]string) {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for k := range want {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	cmd := exec.Command(bash, append([]string{"-c", `__keys=("$@"); set -a --; . "$0"; for k in "${__keys[@]}"; do printf '%s\0' "${!k}"; done`, path}, keys...)...)
+	for k, v := range dotenvTestEnv {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("bash: %v", err)
+	}
+	values := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i, k := range keys {
+		if i < len(values) && values[i] != want[k] {
+			t.Errorf("bash gives %s = %q, want %q", k, values[i], want[k])
+		}
+	}
+}
+
+func TestParseDotEnvOverride(t *testing.T) {
+	src := "E=new\nA=$E\n"
+
+	vars, err := ParseDotEnv("test.env", src, false, dotenvLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// E keeps its environment value, which later lines see.
+	want := []DotEnvVar{{Key: "A", Value: "envval", Line: 2}}
+	if !slices.Equal(vars, want) {
+		t.Errorf("without override: got %v, want %v", vars, want)
+	}
+
+	vars, err = ParseDotEnv("test.env", src, true, dotenvLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []DotEnvVar{{Key: "E", Value: "new", Line: 1}, {Key: "A", Value: "new", Line: 2}}
+	if !slices.Equal(vars, want) {
+		t.Errorf("with override: got %v, want %v", vars, want)
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"A=1\nls -l\n", "test.env:2: expected KEY=VALUE, got \"ls\" (commands are not supported)"},
+		{"A=$(date)\n", "test.env:1: command substitution is not supported"},
+		{"A=1\nB=`date`\n", "test.env:2: command substitution is not supported"},
+		{"A=\"x $(id)\"\n", "test.env:1: command substitution is not supported"},
+		{"A='open\nB=2\n", "test.env:1: unterminated single quote"},
+		{"A=1\nB=\"open\nC=3\n", "test.env:2: unterminated double quote"},
+		{"A=$'open\n", "test.env:1: unterminated $' quote"},
+		{"A=a|b\n", "test.env:1: unquoted '|' in value; quote the value"},
+		{"1A=x\n", "test.env:1: invalid variable name \"1A\""},
+		{"A=${B:?x}\n", "test.env:1: unsupported substitution ${B:...}"},
+		{"A=${UNSET:-`id`}\n", "test.env:1: command substitution is not supported"},
+		{"A=1\nB=${UNSET:-x\n", "test.env:2: unterminated ${UNSET"},
+	}
+	for _, tt := range tests {
+		_, err := ParseDotEnv("test.env", tt.src, true, dotenvLookup)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseDotEnv(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
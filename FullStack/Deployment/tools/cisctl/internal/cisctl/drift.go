@@ -0,0 +1,477 @@
+package cisctl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// driftAttr is one compared attribute of a resource in state and in
+// DigitalOcean, both rendered as strings.
+type driftAttr struct {
+	Name  string
+	State string
+	Live  string
+}
+
+// CheckDrift compares the resources of a Terraform state with DigitalOcean
+// and returns one result per resource type. Resources of the state are
+// looked up by ID; resources in DigitalOcean are in scope as in
+// BuildInventory. Each missing resource, unmanaged resource and drifted
+// attribute is a failing finding.
+func CheckDrift(ctx context.Context, cfg Config, do *DOClient, st *TerraformState) []ControlResult {
+	droplets, dropletsErr := do.ListScopedDroplets(ctx, cfg.EnvTag, cfg.Scope)
+	scoped := map[int]bool{}
+	for _, d := range droplets {
+		scoped[d.ID] = true
+	}
+	tags := cfg.Scope.Tags(cfg.EnvTag)
+	inScope := func(ids []int, resourceTags []string) bool {
+		if slices.ContainsFunc(tags, func(t string) bool { return HasString(resourceTags, t) }) {
+			return true
+		}
+		return slices.ContainsFunc(ids, func(id int) bool { return scoped[id] })
+	}
+
+	checks := []struct {
+		id, title, resourceType string
+		run                     func() ([]Finding, error)
+	}{
+		{"drift:droplet", "Droplets match Terraform state", "droplet", func() ([]Finding, error) {
+			if dropletsErr != nil {
+				return nil, dropletsErr
+			}
+			return driftDroplets(ctx, do, st, droplets)
+		}},
+		{"drift:firewall", "Firewalls match Terraform state", "firewall", func() ([]Finding, error) {
+			return driftFirewalls(ctx, do, st, inScope)
+		}},
+		{"drift:volume", "Volumes match Terraform state", "volume", func() ([]Finding, error) {
+			return driftVolumes(ctx, cfg, do, st, inScope)
+		}},
+		{"drift:alert_policy", "Alert policies match Terraform state", "alert_policy", func() ([]Finding, error) {
+			return driftAlertPolicies(ctx, do, st, inScope)
+		}},
+		{"drift:vpc", "VPCs match Terraform state", "vpc", func() ([]Finding, error) {
+			return driftVPCs(ctx, do, st)
+		}},
+		{"drift:bucket", "Spaces buckets match Terraform state", "bucket", func() ([]Finding, error) {
+			return driftBuckets(ctx, cfg, do, st)
+		}},
+	}
+
+	var results []ControlResult
+	for _, c := range checks {
+		result := ControlResult{ControlID: c.id, Title: c.title, StartedAt: time.Now().UTC()}
+		findings, err := c.run()
+		result.FinishedAt = time.Now().UTC()
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			result.Findings = []Finding{{ResourceType: "control", Pass: false, Reason: err.Error()}}
+		case len(findings) == 0:
+			result.Findings = []Finding{{
+				ResourceType:  c.resourceType,
+				Pass:          true,
+				NotApplicable: true,
+				Reason:        "None in Terraform state or in scope",
+			}}
+		default:
+			result.Findings = findings
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// driftFindings compares the attributes of a resource found both in state and
+// in DigitalOcean: one failing finding per drifted attribute, or one passing
+// finding.
+func driftFindings(resourceType string, id string, name string, address string, attrs []driftAttr) []Finding {
+	var out []Finding
+	for _, a := range attrs {
+		if a.State == a.Live {
+			continue
+		}
+		out = append(out, Finding{
+			ResourceType: resourceType,
+			ResourceID:   id,
+			ResourceName: name,
+			Pass:         false,
+			Reason:       fmt.Sprintf("%s drifted: state %q, live %q", a.Name, a.State, a.Live),
+			Evidence: map[string]string{
+				"address":   address,
+				"attribute": a.Name,
+				"state":     a.State,
+				"live":      a.Live,
+			},
+		})
+	}
+	if len(out) == 0 {
+		out = append(out, Finding{
+			ResourceType: resourceType,
+			ResourceID:   id,
+			ResourceName: name,
+			Pass:         true,
+			Evidence:     map[string]string{"address": address},
+		})
+	}
+	return out
+}
+
+func driftMissing(resourceType string, r TerraformResource) Finding {
+	return Finding{
+		ResourceType: resourceType,
+		ResourceID:   r.String("id"),
+		ResourceName: r.String("name"),
+		Pass:         false,
+		Reason:       "In Terraform state but missing in DigitalOcean",
+		Evidence:     map[string]string{"address": r.Address},
+	}
+}
+
+func driftUnmanaged(resourceType string, id string, name string) Finding {
+	return Finding{
+		ResourceType: resourceType,
+		ResourceID:   id,
+		ResourceName: name,
+		Pass:         false,
+		Reason:       "In DigitalOcean but not in Terraform state",
+	}
+}
+
+// sortedList renders a set for comparison.
+func sortedList(values []string) string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return strings.Join(values, ",")
+}
+
+func intStrings(ids []int) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, strconv.Itoa(id))
+	}
+	return out
+}
+
+func stateBool(r TerraformResource, key string) string {
+	v, _ := r.Bool(key)
+	return yesNo(v)
+}
+
+func driftDroplets(ctx context.Context, do *DOClient, st *TerraformState, live []godo.Droplet) ([]Finding, error) {
+	byID := map[string]godo.Droplet{}
+	for _, d := range live {
+		byID[strconv.Itoa(d.ID)] = d
+	}
+	var out []Finding
+	inState := map[string]bool{}
+	for _, r := range st.ResourcesOfType("digitalocean_droplet") {
+		id := r.String("id")
+		inState[id] = true
+		d, ok := byID[id]
+		if !ok {
+			// A droplet that lost its env tag is drift, not a missing droplet.
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				out = append(out, driftMissing("droplet", r))
+				continue
+			}
+			got, err := do.GetDroplet(ctx, n)
+			if err != nil {
+				return nil, err
+			}
+			if got == nil {
+				out = append(out, driftMissing("droplet", r))
+				continue
+			}
+			d = *got
+		}
+		out = append(out, driftFindings("droplet", id, d.Name, r.Address, []driftAttr{
+			{"backups", stateBool(r, "backups"), yesNo(HasFeature(d, "backups"))},
+			{"monitoring", stateBool(r, "monitoring"), yesNo(HasFeature(d, "monitoring"))},
+			{"vpc_uuid", r.String("vpc_uuid"), d.VPCUUID},
+			{"size", r.String("size"), d.SizeSlug},
+			{"tags", sortedList(r.Strings("tags")), sortedList(d.Tags)},
+		})...)
+	}
+	for _, d := range live {
+		if !inState[strconv.Itoa(d.ID)] {
+			out = append(out, driftUnmanaged("droplet", strconv.Itoa(d.ID), d.Name))
+		}
+	}
+	return out, nil
+}
+
+func driftFirewalls(ctx context.Context, do *DOClient, st *TerraformState, inScope func([]int, []string) bool) ([]Finding, error) {
+	firewalls, err := do.ListFirewalls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]godo.Firewall{}
+	for _, fw := range firewalls {
+		byID[fw.ID] = fw
+	}
+	var out []Finding
+	inState := map[string]bool{}
+	for _, r := range st.ResourcesOfType("digitalocean_firewall") {
+		id := r.String("id")
+		inState[id] = true
+		fw, ok := byID[id]
+		if !ok {
+			out = append(out, driftMissing("firewall", r))
+			continue
+		}
+		out = append(out, driftFindings("firewall", id, fw.Name, r.Address, []driftAttr{
+			{"inbound_rule", stateFirewallRules(r, "inbound_rule", "source"), liveInboundRules(fw.InboundRules)},
+			{"outbound_rule", stateFirewallRules(r, "outbound_rule", "destination"), liveOutboundRules(fw.OutboundRules)},
+			{"droplet_ids", sortedList(r.Strings("droplet_ids")), sortedList(intStrings(fw.DropletIDs))},
+			{"tags", sortedList(r.Strings("tags")), sortedList(fw.Tags)},
+		})...)
+	}
+	for _, fw := range firewalls {
+		if !inState[fw.ID] && inScope(fw.DropletIDs, fw.Tags) {
+			out = append(out, driftUnmanaged("firewall", fw.ID, fw.Name))
+		}
+	}
+	return out, nil
+}
+
+// firewallRule renders a rule for comparison, e.g.
+// "tcp/22 1.2.3.4/32,tag:web". Port ranges that cover every port compare
+// equal whichever way they are written.
+func firewallRule(protocol string, ports string, addresses []string, tags []string, dropletIDs []string, others []string) string {
+	switch ports {
+	case "", "0", "all", "1-65535":
+		ports = "all"
+	}
+	peers := slices.Clone(addresses)
+	for _, t := range tags {
+		peers = append(peers, "tag:"+t)
+	}
+	for _, id := range dropletIDs {
+		peers = append(peers, "droplet:"+id)
+	}
+	peers = append(peers, others...)
+	return protocol + "/" + ports + " " + sortedList(peers)
+}
+
+// stateFirewallRules renders the rule blocks of a digitalocean_firewall,
+// whose peer attributes start with side (source or destination).
+func stateFirewallRules(r TerraformResource, key string, side string) string {
+	var rules []string
+	for _, b := range r.Blocks(key) {
+		var others []string
+		for _, id := range anyStrings(b[side+"_load_balancer_uids"]) {
+			others = append(others, "lb:"+id)
+		}
+		for _, id := range anyStrings(b[side+"_kubernetes_ids"]) {
+			others = append(others, "k8s:"+id)
+		}
+		protocol, _ := b["protocol"].(string)
+		ports, _ := b["port_range"].(string)
+		rules = append(rules, firewallRule(protocol, ports, anyStrings(b[side+"_addresses"]), anyStrings(b[side+"_tags"]), anyStrings(b[side+"_droplet_ids"]), others))
+	}
+	slices.Sort(rules)
+	return strings.Join(rules, "; ")
+}
+
+func liveFirewallRule(protocol string, ports string, peers *godo.Sources) string {
+	if peers == nil {
+		peers = &godo.Sources{}
+	}
+	var others []string
+	for _, id := range peers.LoadBalancerUIDs {
+		others = append(others, "lb:"+id)
+	}
+	for _, id := range peers.KubernetesIDs {
+		others = append(others, "k8s:"+id)
+	}
+	return firewallRule(protocol, ports, peers.Addresses, peers.Tags, intStrings(peers.DropletIDs), others)
+}
+
+func liveInboundRules(rules []godo.InboundRule) string {
+	var out []string
+	for _, r := range rules {
+		out = append(out, liveFirewallRule(r.Protocol, r.PortRange, r.Sources))
+	}
+	slices.Sort(out)
+	return strings.Join(out, "; ")
+}
+
+func liveOutboundRules(rules []godo.OutboundRule) string {
+	var out []string
+	for _, r := range rules {
+		var peers *godo.Sources
+		if d := r.Destinations; d != nil {
+			peers = &godo.Sources{
+				Addresses:        d.Addresses,
+				Tags:             d.Tags,
+				DropletIDs:       d.DropletIDs,
+				LoadBalancerUIDs: d.LoadBalancerUIDs,
+				KubernetesIDs:    d.KubernetesIDs,
+			}
+		}
+		out = append(out, liveFirewallRule(r.Protocol, r.PortRange, peers))
+	}
+	slices.Sort(out)
+	return strings.Join(out, "; ")
+}
+
+func driftVolumes(ctx context.Context, cfg Config, do *DOClient, st *TerraformState, inScope func([]int, []string) bool) ([]Finding, error) {
+	volumes, err := do.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]godo.Volume{}
+	for _, v := range volumes {
+		byID[v.ID] = v
+	}
+	var out []Finding
+	inState := map[string]bool{}
+	for _, r := range st.ResourcesOfType("digitalocean_volume") {
+		id := r.String("id")
+		inState[id] = true
+		v, ok := byID[id]
+		if !ok {
+			out = append(out, driftMissing("volume", r))
+			continue
+		}
+		size, _ := r.Number("size")
+		out = append(out, driftFindings("volume", id, v.Name, r.Address, []driftAttr{
+			{"size", strconv.FormatFloat(size, 'f', -1, 64), strconv.FormatInt(v.SizeGigaBytes, 10)},
+			{"tags", sortedList(r.Strings("tags")), sortedList(v.Tags)},
+		})...)
+	}
+	for _, v := range volumes {
+		if inState[v.ID] || !inScope(v.DropletIDs, v.Tags) {
+			continue
+		}
+		if ok, err := cfg.Scope.MatchVolume(ctx, do, v); err != nil {
+			return nil, err
+		} else if ok {
+			out = append(out, driftUnmanaged("volume", v.ID, v.Name))
+		}
+	}
+	return out, nil
+}
+
+func driftAlertPolicies(ctx context.Context, do *DOClient, st *TerraformState, inScope func([]int, []string) bool) ([]Finding, error) {
+	policies, err := do.ListAlertPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]godo.AlertPolicy{}
+	for _, p := range policies {
+		byID[p.UUID] = p
+	}
+	var out []Finding
+	inState := map[string]bool{}
+	for _, r := range st.ResourcesOfType("digitalocean_monitor_alert") {
+		id := r.String("id")
+		inState[id] = true
+		p, ok := byID[id]
+		if !ok {
+			m := driftMissing("alert_policy", r)
+			m.ResourceName = r.String("description")
+			out = append(out, m)
+			continue
+		}
+		value, _ := r.Number("value")
+		out = append(out, driftFindings("alert_policy", id, p.Description, r.Address, []driftAttr{
+			{"enabled", stateBool(r, "enabled"), yesNo(p.Enabled)},
+			{"threshold", fmt.Sprintf("%s %g for %s", r.String("compare"), value, r.String("window")), fmt.Sprintf("%s %g for %s", p.Compare, p.Value, p.Window)},
+			{"entities", sortedList(r.Strings("entities")), sortedList(p.Entities)},
+			{"tags", sortedList(r.Strings("tags")), sortedList(p.Tags)},
+		})...)
+	}
+	for _, p := range policies {
+		var ids []int
+		for _, e := range p.Entities {
+			if id, err := strconv.Atoi(e); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		if !inState[p.UUID] && inScope(ids, p.Tags) {
+			out = append(out, driftUnmanaged("alert_policy", p.UUID, p.Description))
+		}
+	}
+	return out, nil
+}
+
+// driftVPCs only checks the VPCs of the state: droplets in VPCs Terraform does
+// not manage show up as vpc_uuid drift.
+func driftVPCs(ctx context.Context, do *DOClient, st *TerraformState) ([]Finding, error) {
+	resources := st.ResourcesOfType("digitalocean_vpc")
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	vpcs, err := do.ListVPCs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Finding
+	for _, r := range resources {
+		i := slices.IndexFunc(vpcs, func(v *godo.VPC) bool { return v.ID == r.String("id") })
+		if i < 0 {
+			out = append(out, driftMissing("vpc", r))
+			continue
+		}
+		v := vpcs[i]
+		out = append(out, driftFindings("vpc", v.ID, v.Name, r.Address, []driftAttr{
+			{"ip_range", r.String("ip_range"), v.IPRange},
+		})...)
+	}
+	return out, nil
+}
+
+// driftBuckets compares bucket names only; it needs the Spaces key. The
+// TFSTATE_BUCKET backend bucket is skipped, as in
+// scripts/bash/controls/spaces_2.3.7_destroy_unused_buckets.sh.
+func driftBuckets(ctx context.Context, cfg Config, do *DOClient, st *TerraformState) ([]Finding, error) {
+	resources := st.ResourcesOfType("digitalocean_spaces_bucket")
+	if cfg.SpacesAccessKey == "" || cfg.SpacesSecretKey == "" {
+		if len(resources) == 0 {
+			return nil, nil
+		}
+		return []Finding{{
+			ResourceType:  "bucket",
+			Pass:          true,
+			NotApplicable: true,
+			Reason:        "Spaces credentials not set (SPACES_ACCESS_KEY_ID, SPACES_SECRET_ACCESS_KEY); buckets not compared",
+		}}, nil
+	}
+	buckets, err := ListSpacesBuckets(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var out []Finding
+	inState := map[string]bool{}
+	for _, r := range resources {
+		name := r.String("name")
+		inState[name] = true
+		if !slices.ContainsFunc(buckets, func(b SpacesBucket) bool { return b.Name == name }) {
+			out = append(out, driftMissing("bucket", r))
+			continue
+		}
+		out = append(out, driftFindings("bucket", name, name, r.Address, nil)...)
+	}
+	for _, b := range buckets {
+		if inState[b.Name] || b.Name == os.Getenv("TFSTATE_BUCKET") {
+			continue
+		}
+		if ok, err := cfg.Scope.MatchBucket(ctx, do, b.Name, cfg.SpacesRegion); err != nil {
+			return nil, err
+		} else if ok {
+			out = append(out, driftUnmanaged("bucket", b.Name, b.Name))
+		}
+	}
+	return out, nil
+}
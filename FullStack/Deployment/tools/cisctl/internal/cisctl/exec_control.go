@@ -0,0 +1,571 @@
+package cisctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecProtocolVersion is the version of the exec control protocol, sent to
+// every control as protocol_version and CISCTL_PROTOCOL_VERSION.
+//
+// An exec control is an external executable that cisctl runs as a control,
+// such as scripts/bash/controls/*.sh. cisctl runs it in the deployment root
+// with a JSON ExecRequest on stdin, the environment of cisctl plus the
+// variables set by execEnv, and logs every line it prints into the control's
+// log. Scripts (*.sh) run with bash; other files must be executable.
+//
+// The control writes an ExecResult to the file named by CISCTL_RESULT_FILE.
+// A control that writes none is read the way scripts/bash/controls report:
+// the newest *_<id>_*.json in REPORT_DIR, a directory under the report dir
+// made for each run so that other runs' reports are never picked up, whose
+// `failed` entries become failing findings. A control without either fails
+// with its exit status and last output lines.
+//
+// The control ID comes from a "# cisctl-id:" line in the first lines of the
+// file, else from the x.y.z number in its name; the title from a
+// "# cisctl-title:" line, else the file name.
+const ExecProtocolVersion = 1
+
+// ExecRequest is the JSON object an exec control reads on stdin.
+type ExecRequest struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	ControlID       string `json:"control_id"`
+	ResultFile      string `json:"result_file"`
+	EnvTag          string `json:"env_tag"`
+	RootDir         string `json:"root_dir"`
+	ReportDir       string `json:"report_dir"`
+	LogDir          string `json:"log_dir"`
+	// Scope is the droplet scope beyond env_tag, if any.
+	Scope *ReportScope `json:"scope,omitempty"`
+	Hosts struct {
+		Source string   `json:"source"`
+		Groups []string `json:"groups,omitempty"`
+	} `json:"hosts"`
+	SSH struct {
+		User           string `json:"user"`
+		UserFallback   string `json:"user_fallback"`
+		KeyPath        string `json:"key_path,omitempty"`
+		Port           int    `json:"port"`
+		TimeoutSeconds int    `json:"timeout_seconds"`
+	} `json:"ssh"`
+	// Spaces holds the Spaces settings and keys, for controls that check
+	// buckets; the request comes on stdin and is never written to disk.
+	Spaces struct {
+		Region          string `json:"region"`
+		Endpoint        string `json:"endpoint"`
+		AccessKeyID     string `json:"access_key_id,omitempty"`
+		SecretAccessKey string `json:"secret_access_key,omitempty"`
+	} `json:"spaces"`
+	// Params are the control_params of the control's ID.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// ExecResult is the JSON object an exec control writes to its result file.
+// Findings have the fields of the report's findings; a set Error fails the
+// control like a Go control's error.
+type ExecResult struct {
+	ProtocolVersion int       `json:"protocol_version"`
+	ControlID       string    `json:"control_id"`
+	Notes           string    `json:"notes"`
+	Error           string    `json:"error"`
+	Findings        []Finding `json:"findings"`
+}
+
+// ExecControl runs an external executable as a control.
+type ExecControl struct {
+	Path  string
+	id    string
+	title string
+}
+
+func (c *ExecControl) ID() string    { return c.id }
+func (c *ExecControl) Title() string { return c.title }
+
+func (c *ExecControl) Describe() ControlDescription {
+	runner := "executable"
+	if filepath.Ext(c.Path) == ".sh" {
+		runner = "bash"
+	}
+	return ControlDescription{
+		Source: c.Path,
+		Metadata: map[string]string{
+			"kind":             "exec",
+			"path":             c.Path,
+			"protocol_version": strconv.Itoa(ExecProtocolVersion),
+			"runner":           runner,
+		},
+	}
+}
+
+var (
+	execIDRe     = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+	execHeaderRe = regexp.MustCompile(`^#\s*cisctl-(id|title):\s*(.+?)\s*$`)
+)
+
+// LoadExecControls returns the exec controls of patterns: files, directories
+// (every file in them) or globs, relative to rootDir. Each must match a file.
+func LoadExecControls(rootDir string, patterns []string) ([]*ExecControl, error) {
+	var paths []string
+	for _, p := range patterns {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(rootDir, p)
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("exec controls %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("exec controls %q: no such file", p)
+		}
+		for _, m := range matches {
+			st, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			if !st.IsDir() {
+				paths = append(paths, m)
+				continue
+			}
+			entries, err := os.ReadDir(m)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+					paths = append(paths, filepath.Join(m, e.Name()))
+				}
+			}
+		}
+	}
+
+	var out []*ExecControl
+	seen := map[string]string{}
+	for _, p := range paths {
+		if slices.ContainsFunc(out, func(c *ExecControl) bool { return c.Path == p }) {
+			continue
+		}
+		c, err := newExecControl(p)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := seen[c.id]; ok {
+			return nil, fmt.Errorf("%s: control %s is already defined by %s", p, c.id, prev)
+		}
+		seen[c.id] = p
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func newExecControl(path string) (*ExecControl, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) != ".sh" && st.Mode()&0o111 == 0 {
+		return nil, fmt.Errorf("%s: not executable", path)
+	}
+	base := filepath.Base(path)
+	c := &ExecControl{Path: path, id: execIDRe.FindString(base), title: strings.TrimSuffix(base, filepath.Ext(base))}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for i := 0; i < 10 && sc.Scan(); i++ {
+		m := execHeaderRe.FindStringSubmatch(sc.Text())
+		switch {
+		case m == nil:
+		case m[1] == "id":
+			c.id = m[2]
+		default:
+			c.title = m[2]
+		}
+	}
+	if c.id == "" {
+		return nil, fmt.Errorf("%s: no control ID (add a \"# cisctl-id:\" line)", path)
+	}
+	return c, nil
+}
+
+func (c *ExecControl) Run(ctx context.Context, deps Deps) (ControlOutcome, error) {
+	var out ControlOutcome
+	cfg := deps.Config
+
+	timeout := cfg.ExecTimeout
+	if v := cfg.ControlParam(c.id, "timeout", ""); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return out, fmt.Errorf("invalid timeout param %q: %w", v, err)
+		}
+		timeout = d
+	}
+
+	resultFile, err := os.CreateTemp("", "cisctl-result-*.json")
+	if err != nil {
+		return out, err
+	}
+	resultFile.Close()
+	defer os.Remove(resultFile.Name())
+
+	// Each run gets its own REPORT_DIR; it is removed when left empty.
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		return out, err
+	}
+	if cfg.ReportDir, err = os.MkdirTemp(cfg.ReportDir, "exec-"+c.id+"-"); err != nil {
+		return out, err
+	}
+	defer os.Remove(cfg.ReportDir)
+
+	stdin, err := json.Marshal(c.request(cfg, resultFile.Name()))
+	if err != nil {
+		return out, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if filepath.Ext(c.Path) == ".sh" {
+		cmd = exec.CommandContext(runCtx, "bash", c.Path)
+	} else {
+		cmd = exec.CommandContext(runCtx, c.Path)
+	}
+	// Children such as doctl may hold the output pipes after the script is
+	// killed; stop waiting for them shortly after.
+	cmd.WaitDelay = 5 * time.Second
+	cmd.Dir = cfg.RootDir
+	cmd.Env = append(os.Environ(), execEnv(cfg, c.id, resultFile.Name())...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	output := &execOutput{log: deps.Log}
+	cmd.Stdout = output.stream("stdout")
+	cmd.Stderr = output.stream("stderr")
+
+	deps.Log.Info("exec control started", "path", c.Path, "timeout", timeout.String())
+	runErr := cmd.Run()
+	output.flush()
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return out, fmt.Errorf("%s timed out after %s", filepath.Base(c.Path), timeout)
+	}
+	if ctx.Err() != nil {
+		return out, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return out, runErr
+	}
+	deps.Log.Info("exec control exited", "exit_code", cmd.ProcessState.ExitCode())
+
+	if data, err := os.ReadFile(resultFile.Name()); err != nil {
+		return out, err
+	} else if len(bytes.TrimSpace(data)) > 0 {
+		var res ExecResult
+		if err := json.Unmarshal(data, &res); err != nil {
+			return out, fmt.Errorf("invalid result object: %w", err)
+		}
+		if res.ControlID != "" && res.ControlID != c.id {
+			return out, fmt.Errorf("result object is for control %s", res.ControlID)
+		}
+		if res.Error != "" {
+			return out, errors.New(res.Error)
+		}
+		out.Notes = res.Notes
+		out.Findings = res.Findings
+		return out, nil
+	}
+
+	reportPath, err := latestLegacyReport(cfg.ReportDir, c.id)
+	if err != nil {
+		return out, err
+	}
+	if reportPath == "" {
+		if runErr != nil {
+			return out, fmt.Errorf("%s exited with status %d: %s", filepath.Base(c.Path), exitErr.ExitCode(), output.lastLines())
+		}
+		return out, fmt.Errorf("%s wrote no result object or report", filepath.Base(c.Path))
+	}
+	deps.Log.Info("reading legacy report", "path", reportPath)
+	return readLegacyReport(reportPath)
+}
+
+func (c *ExecControl) request(cfg Config, resultFile string) ExecRequest {
+	req := ExecRequest{
+		ProtocolVersion: ExecProtocolVersion,
+		ControlID:       c.id,
+		ResultFile:      resultFile,
+		EnvTag:          cfg.EnvTag,
+		RootDir:         cfg.RootDir,
+		ReportDir:       cfg.ReportDir,
+		LogDir:          cfg.LogDir,
+		Scope:           cfg.reportScope(),
+		Params:          cfg.ControlParams[c.id],
+	}
+	req.Hosts.Source = firstNonEmpty(cfg.HostsSource, HostSourceDO)
+	req.Hosts.Groups = cfg.HostGroups
+	req.SSH.User = cfg.SSHUser
+	req.SSH.UserFallback = cfg.SSHUserFallback
+	req.SSH.KeyPath = cfg.SSHKeyPath
+	req.SSH.Port = cfg.SSHPort
+	req.SSH.TimeoutSeconds = int(cfg.SSHTimeout / time.Second)
+	req.Spaces.Region = cfg.SpacesRegion
+	req.Spaces.Endpoint = cfg.SpacesEndpoint
+	req.Spaces.AccessKeyID = cfg.SpacesAccessKey
+	req.Spaces.SecretAccessKey = cfg.SpacesSecretKey
+	return req
+}
+
+// execEnv returns the variables set for an exec control: the protocol's and
+// the settings the bash controls read. Controls only check, so destructive
+// scripts such as 2.3.7 always run dry.
+func execEnv(cfg Config, id string, resultFile string) []string {
+	env := []string{
+		"CISCTL_PROTOCOL_VERSION=" + strconv.Itoa(ExecProtocolVersion),
+		"CISCTL_CONTROL_ID=" + id,
+		"CISCTL_RESULT_FILE=" + resultFile,
+		"ENV_TAG=" + cfg.EnvTag,
+		"REPORT_DIR=" + cfg.ReportDir,
+		"LOG_DIR=" + cfg.LogDir,
+		"SSH_USER=" + cfg.SSHUser,
+		"SSH_USER_FALLBACK=" + cfg.SSHUserFallback,
+		"SSH_KEY_PATH=" + cfg.SSHKeyPath,
+		"SSH_PORT=" + strconv.Itoa(cfg.SSHPort),
+		"SSH_TIMEOUT_SECONDS=" + strconv.Itoa(int(cfg.SSHTimeout/time.Second)),
+		"SPACES_REGION=" + cfg.SpacesRegion,
+		"SPACES_ENDPOINT=" + cfg.SpacesEndpoint,
+		"DRY_RUN=1",
+		"APPROVE_DELETE=0",
+	}
+	// doctl reads the token from DIGITALOCEAN_ACCESS_TOKEN.
+	if cfg.DOAccessToken != "" {
+		env = append(env, "DIGITALOCEAN_ACCESS_TOKEN="+cfg.DOAccessToken)
+	}
+	// The scripts prefer the AWS_* names, so both carry cisctl's keys.
+	if cfg.SpacesAccessKey != "" {
+		env = append(env, "SPACES_ACCESS_KEY_ID="+cfg.SpacesAccessKey, "AWS_ACCESS_KEY_ID="+cfg.SpacesAccessKey)
+	}
+	if cfg.SpacesSecretKey != "" {
+		env = append(env, "SPACES_SECRET_ACCESS_KEY="+cfg.SpacesSecretKey, "AWS_SECRET_ACCESS_KEY="+cfg.SpacesSecretKey)
+	}
+	return env
+}
+
+// execOutput logs the output lines of an exec control and keeps the last
+// ones for error messages.
+type execOutput struct {
+	log  *Logger
+	mu   sync.Mutex
+	tail []string
+	bufs []*execStream
+}
+
+type execStream struct {
+	o    *execOutput
+	name string
+	buf  []byte
+}
+
+func (o *execOutput) stream(name string) io.Writer {
+	s := &execStream{o: o, name: name}
+	o.bufs = append(o.bufs, s)
+	return s
+}
+
+func (s *execStream) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		s.o.line(s.name, string(s.buf[:i]))
+		s.buf = s.buf[i+1:]
+	}
+}
+
+func (o *execOutput) line(stream string, line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.log.Info(line, "stream", stream)
+	o.tail = append(o.tail, line)
+	if len(o.tail) > 3 {
+		o.tail = o.tail[1:]
+	}
+}
+
+func (o *execOutput) flush() {
+	for _, s := range o.bufs {
+		if len(s.buf) > 0 {
+			o.line(s.name, string(s.buf))
+			s.buf = nil
+		}
+	}
+}
+
+func (o *execOutput) lastLines() string {
+	if len(o.tail) == 0 {
+		return "no output"
+	}
+	return strings.Join(o.tail, " | ")
+}
+
+// latestLegacyReport returns the newest report of control id in dir, or ""
+// when there is none. The bash controls name their reports
+// <prefix>_<id>_<timestamp>.json.
+func latestLegacyReport(dir string, id string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*_"+id+"_*.json"))
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var latestMod time.Time
+	for _, m := range matches {
+		st, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if latest == "" || st.ModTime().After(latestMod) {
+			latest, latestMod = m, st.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// readLegacyReport converts a bash control report: one failing finding per
+// `failed` entry, and a failing control finding when the report fails
+// without entries.
+func readLegacyReport(path string) (ControlOutcome, error) {
+	var out ControlOutcome
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return out, err
+	}
+	var doc struct {
+		Pass   *bool            `json:"pass"`
+		Notes  string           `json:"notes"`
+		Failed []map[string]any `json:"failed"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return out, fmt.Errorf("%s: %w", path, err)
+	}
+	if doc.Pass == nil {
+		return out, fmt.Errorf("%s: not a control report (no pass field)", path)
+	}
+
+	out.Notes = "Report: " + path
+	if doc.Notes != "" {
+		out.Notes = doc.Notes + " (report: " + path + ")"
+	}
+	for _, entry := range doc.Failed {
+		out.Findings = append(out.Findings, legacyFinding(entry))
+	}
+	if !*doc.Pass && len(out.Findings) == 0 {
+		out.Findings = append(out.Findings, Finding{
+			ResourceType: "control",
+			Pass:         false,
+			Reason:       "script reported failure",
+		})
+	}
+	return out, nil
+}
+
+// legacyResourceKeys maps the resource keys of `failed` entries, most
+// specific first, to finding resource types.
+var legacyResourceKeys = []struct{ key, resourceType string }{
+	{"volume", "volume"},
+	{"bucket", "bucket"},
+	{"fingerprint", "ssh_key"},
+	{"droplet", "droplet"},
+}
+
+func legacyFinding(entry map[string]any) Finding {
+	f := Finding{ResourceType: "control", Evidence: map[string]string{}}
+	used := map[string]bool{"reason": true, "ip": true}
+	f.Reason = legacyString(entry["reason"])
+	f.IP = legacyString(entry["ip"])
+
+	for _, rk := range legacyResourceKeys {
+		if _, ok := entry[rk.key]; !ok {
+			continue
+		}
+		f.ResourceType = rk.resourceType
+		switch rk.key {
+		case "fingerprint":
+			f.ResourceID = legacyString(entry["id"])
+			f.ResourceName = legacyString(entry["name"])
+			used["id"], used["name"] = true, true
+		case "droplet":
+			f.ResourceID = legacyString(entry["droplet_id"])
+			f.ResourceName = legacyString(entry["droplet"])
+			used["droplet_id"], used["droplet"] = true, true
+		default:
+			f.ResourceName = legacyString(entry[rk.key])
+			used[rk.key] = true
+		}
+		break
+	}
+
+	// The remaining fields become evidence; nested objects such as checks
+	// and signals are flattened to their own keys.
+	keys := make([]string, 0, len(entry))
+	for k := range entry {
+		if !used[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if nested, ok := entry[k].(map[string]any); ok {
+			for nk, nv := range nested {
+				if _, taken := f.Evidence[nk]; !taken && nv != nil {
+					f.Evidence[nk] = legacyString(nv)
+				}
+			}
+			continue
+		}
+		if entry[k] != nil {
+			f.Evidence[k] = legacyString(entry[k])
+		}
+	}
+	if len(f.Evidence) == 0 {
+		f.Evidence = nil
+	}
+	return f
+}
+
+func legacyString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
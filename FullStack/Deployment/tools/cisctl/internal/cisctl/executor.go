@@ -0,0 +1,70 @@
+package cisctl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Executor runs shell commands on a host for host controls and host
+// remediation. Executors bound to one machine ignore h.
+type Executor interface {
+	RunCommand(h Host, cmd string) (string, error)
+}
+
+// LocalExecutor runs commands on the machine cisctl runs on, for agent-style
+// use from cloud-init or on hosts that cannot be reached over SSH. Timeout
+// bounds each command (Config.LocalTimeout); zero means no limit, as for
+// commands run over SSH, whose timeout only covers connecting. Package
+// installs during remediation can take minutes.
+type LocalExecutor struct {
+	Timeout time.Duration
+}
+
+// RunCommand runs cmd through sh and returns its trimmed combined output.
+// Root does not need sudo, which minimal images often lack, so a leading
+// `sudo -n` is dropped when cisctl runs as root.
+func (e LocalExecutor) RunCommand(h Host, cmd string) (string, error) {
+	if os.Geteuid() == 0 {
+		if rest, ok := strings.CutPrefix(cmd, "sudo -n "); ok {
+			cmd = rest
+		}
+	}
+	ctx := context.Background()
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	// Do not wait forever for children that keep the output pipe open.
+	c.WaitDelay = time.Second
+	b, err := c.CombinedOutput()
+	if ctx.Err() != nil {
+		err = fmt.Errorf("command timed out after %s: %w", e.Timeout, ctx.Err())
+	}
+	return strings.TrimSpace(string(b)), err
+}
+
+// LocalHost is the host source of local runs: the machine cisctl runs on.
+type LocalHost struct{}
+
+func (LocalHost) Hosts(ctx context.Context) ([]Host, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return []Host{{
+		Name:         name,
+		Address:      "localhost",
+		ResourceType: "host",
+		ResourceID:   name,
+	}}, nil
+}
+
+func (LocalHost) String() string {
+	return "local host"
+}
@@ -0,0 +1,102 @@
+package cisctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HostPlan is an ordered list of commands that fix a host. Every
+// file in Backup is copied aside before the first command runs; if any
+// command fails, the copies are restored (files that did not exist are
+// removed) and Rollback is run. Installed packages are not rolled back.
+type HostPlan struct {
+	Backup   []string
+	Ops      []HostOp
+	Rollback string
+}
+
+type HostOp struct {
+	Desc string
+	Cmd  string
+	// AsUser runs Cmd as the SSH user instead of through sudo.
+	AsUser bool
+}
+
+// Apply runs the plan on h and logs each operation.
+func (p HostPlan) Apply(ctx context.Context, ex Executor, h Host, log *Logger) error {
+	suffix := ".cisctl-bak-" + time.Now().UTC().Format("20060102150405")
+
+	var saved, created []string
+	for _, path := range p.Backup {
+		out, err := ex.RunCommand(h, sudoCommand(fmt.Sprintf(
+			`if [ -e %[1]s ]; then cp -a %[1]s %[2]s && echo saved; else echo absent; fi`,
+			shellQuote(path), shellQuote(path+suffix))))
+		if err != nil {
+			p.restore(h, ex, suffix, saved, created, log)
+			return fmt.Errorf("backup %s: %w: %s", path, err, out)
+		}
+		if strings.TrimSpace(out) == "saved" {
+			saved = append(saved, path)
+			log.Info("backed up file", "path", path, "backup", path+suffix)
+		} else {
+			created = append(created, path)
+		}
+	}
+
+	for _, op := range p.Ops {
+		if err := ctx.Err(); err != nil {
+			p.restore(h, ex, suffix, saved, created, log)
+			return err
+		}
+		cmd := op.Cmd
+		if !op.AsUser {
+			cmd = sudoCommand(cmd)
+		}
+		out, err := ex.RunCommand(h, cmd)
+		if err != nil {
+			log.Error("host step failed", "step", op.Desc, "error", err.Error(), "output", out)
+			if rerr := p.restore(h, ex, suffix, saved, created, log); rerr != nil {
+				return fmt.Errorf("%s: %w (rollback failed: %v)", op.Desc, err, rerr)
+			}
+			return fmt.Errorf("%s: %w (rolled back)", op.Desc, err)
+		}
+		log.Info("host step applied", "step", op.Desc)
+	}
+	return nil
+}
+
+func (p HostPlan) restore(h Host, ex Executor, suffix string, saved []string, created []string, log *Logger) error {
+	var errs []error
+	for _, path := range saved {
+		if out, err := ex.RunCommand(h, sudoCommand(fmt.Sprintf("cp -a %s %s", shellQuote(path+suffix), shellQuote(path)))); err != nil {
+			errs = append(errs, fmt.Errorf("restore %s: %w: %s", path, err, out))
+			continue
+		}
+		log.Warn("restored file", "path", path)
+	}
+	for _, path := range created {
+		if out, err := ex.RunCommand(h, sudoCommand("rm -f "+shellQuote(path))); err != nil {
+			errs = append(errs, fmt.Errorf("remove %s: %w: %s", path, err, out))
+			continue
+		}
+		log.Warn("removed file", "path", path)
+	}
+	if p.Rollback != "" {
+		if out, err := ex.RunCommand(h, sudoCommand(p.Rollback)); err != nil {
+			errs = append(errs, fmt.Errorf("rollback command: %w: %s", err, out))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sudoCommand runs cmd through sh as root; sudo -n fails instead of prompting.
+func sudoCommand(cmd string) string {
+	return "sudo -n sh -c " + shellQuote(cmd)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
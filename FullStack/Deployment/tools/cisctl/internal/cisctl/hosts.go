@@ -0,0 +1,524 @@
+package cisctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"gopkg.in/yaml.v3"
+)
+
+// Host source kinds accepted in HOSTS_SOURCE and --hosts.
+const (
+	HostSourceDO      = "do"
+	HostSourceAnsible = "ansible"
+	HostSourceStatic  = "static"
+)
+
+// Host is a machine the host controls check over SSH. User, Port and KeyPath
+// override the SSH settings of the config when set.
+type Host struct {
+	Name    string
+	Address string
+	User    string
+	Port    int
+	KeyPath string
+	Groups  []string
+
+	// ResourceType and ResourceID identify the host in findings: "droplet"
+	// and the droplet ID for DO hosts, "host" and Name otherwise.
+	ResourceType string
+	ResourceID   string
+}
+
+// ID identifies the host among the hosts of a run. Inventory hosts may share
+// an address and differ only in port or user, so executors key their
+// per-host settings on it rather than on Address.
+func (h Host) ID() string {
+	return h.ResourceType + ":" + h.ResourceID
+}
+
+// HostSource lists the hosts the host controls run against.
+type HostSource interface {
+	Hosts(ctx context.Context) ([]Host, error)
+	// String describes the source for findings and messages.
+	String() string
+}
+
+// NewHostSource returns the source selected by cfg.HostsSource, or the local
+// host with cfg.Local. DO hosts are the droplets in the run's scope.
+func NewHostSource(cfg Config, do *DOClient) (HostSource, error) {
+	if cfg.Local {
+		return LocalHost{}, nil
+	}
+	kind, path, err := parseHostsSource(cfg.HostsSource)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case HostSourceAnsible:
+		return AnsibleHosts{Path: path, Groups: cfg.HostGroups}, nil
+	case HostSourceStatic:
+		return StaticHosts{Path: path, Groups: cfg.HostGroups}, nil
+	default:
+		return DOHosts{DO: do, EnvTag: cfg.EnvTag, Scope: cfg.Scope}, nil
+	}
+}
+
+// parseHostsSource splits "ansible:<path>" or "static:<path>". A bare path is
+// an Ansible inventory unless it ends in .yaml or .yml.
+func parseHostsSource(spec string) (string, string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == HostSourceDO {
+		return HostSourceDO, "", nil
+	}
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok || (kind != HostSourceAnsible && kind != HostSourceStatic) {
+		kind, path = HostSourceAnsible, spec
+		if ext := filepath.Ext(spec); ext == ".yaml" || ext == ".yml" {
+			kind = HostSourceStatic
+		}
+	}
+	if strings.TrimSpace(path) == "" {
+		return "", "", fmt.Errorf("invalid hosts source %q (want do, ansible:<hosts.ini> or static:<hosts.yaml>)", spec)
+	}
+	return kind, path, nil
+}
+
+// DOHosts lists the droplets in scope by their public IPv4 address. Droplets
+// without one are returned with an empty Address.
+type DOHosts struct {
+	DO     *DOClient
+	EnvTag string
+	Scope  Scope
+}
+
+func (s DOHosts) Hosts(ctx context.Context) ([]Host, error) {
+	droplets, err := s.DO.ListScopedDroplets(ctx, s.EnvTag, s.Scope)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]Host, 0, len(droplets))
+	for _, d := range droplets {
+		hosts = append(hosts, DropletHost(d))
+	}
+	return hosts, nil
+}
+
+func (s DOHosts) String() string {
+	return s.Scope.report(s.EnvTag).String()
+}
+
+// DropletHost returns the host for droplet d.
+func DropletHost(d godo.Droplet) Host {
+	return Host{
+		Name:         d.Name,
+		Address:      DropletPublicIPv4(d),
+		Groups:       d.Tags,
+		ResourceType: "droplet",
+		ResourceID:   strconv.Itoa(d.ID),
+	}
+}
+
+// AnsibleHosts reads an Ansible INI inventory such as
+// ansible/inventory/hosts.ini. Groups limits the result to hosts in any of
+// the groups, directly or through :children; empty means all hosts.
+type AnsibleHosts struct {
+	Path   string
+	Groups []string
+}
+
+func (s AnsibleHosts) Hosts(ctx context.Context) ([]Host, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	inv, err := parseAnsibleINI(s.Path, f)
+	if err != nil {
+		return nil, err
+	}
+	return filterHostGroups(inv, s.Groups)
+}
+
+func (s AnsibleHosts) String() string {
+	return hostSourceString(HostSourceAnsible, s.Path, s.Groups)
+}
+
+// parseAnsibleINI reads hosts, [group], [group:vars] and [group:children]
+// sections. Host vars win over the vars of the host's own groups, which win
+// over those of parent groups and then [all:vars]. Of the connection vars,
+// ansible_host, ansible_user, ansible_port and ansible_ssh_private_key_file
+// (and their ansible_ssh_* aliases) are used; the rest are ignored.
+func parseAnsibleINI(path string, r io.Reader) ([]Host, error) {
+	type entry struct {
+		name string
+		vars map[string]string
+	}
+	var (
+		order     []string
+		hosts     = map[string]*entry{}
+		members   = map[string][]string{}
+		children  = map[string][]string{}
+		groupVars = map[string]map[string]string{}
+	)
+
+	section, kind := "ungrouped", ""
+	sc := bufio.NewScanner(r)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: invalid section header %q", path, lineNo, line)
+			}
+			section, kind, _ = strings.Cut(line[1:len(line)-1], ":")
+			if kind != "" && kind != "vars" && kind != "children" {
+				return nil, fmt.Errorf("%s:%d: unknown section type %q", path, lineNo, kind)
+			}
+			if section == "" {
+				return nil, fmt.Errorf("%s:%d: empty group name", path, lineNo)
+			}
+			if _, ok := members[section]; !ok {
+				members[section] = nil
+			}
+			continue
+		}
+
+		fields, err := splitINIFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected key=value in [%s:vars]", path, lineNo, section)
+			}
+			if groupVars[section] == nil {
+				groupVars[section] = map[string]string{}
+			}
+			groupVars[section][strings.TrimSpace(key)] = unquoteINI(strings.TrimSpace(value))
+		case "children":
+			children[section] = append(children[section], fields[0])
+		default:
+			names, err := expandHostPattern(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+			vars := map[string]string{}
+			for _, f := range fields[1:] {
+				key, value, ok := strings.Cut(f, "=")
+				if !ok {
+					return nil, fmt.Errorf("%s:%d: expected key=value after host, got %q", path, lineNo, f)
+				}
+				vars[key] = value
+			}
+			for _, name := range names {
+				e, ok := hosts[name]
+				if !ok {
+					e = &entry{name: name, vars: map[string]string{}}
+					hosts[name] = e
+					order = append(order, name)
+				}
+				for k, v := range vars {
+					e.vars[k] = v
+				}
+				if !slices.Contains(members[section], name) {
+					members[section] = append(members[section], name)
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// groupsOf returns the host's groups, direct ones first, then their
+	// parents through :children.
+	parents := map[string][]string{}
+	for parent, kids := range children {
+		for _, kid := range kids {
+			parents[kid] = append(parents[kid], parent)
+		}
+	}
+	for _, ps := range parents {
+		slices.Sort(ps)
+	}
+	groupsOf := func(name string) []string {
+		var groups []string
+		for g, names := range members {
+			if slices.Contains(names, name) {
+				groups = append(groups, g)
+			}
+		}
+		slices.Sort(groups)
+		for i := 0; i < len(groups); i++ {
+			for _, p := range parents[groups[i]] {
+				if !slices.Contains(groups, p) {
+					groups = append(groups, p)
+				}
+			}
+		}
+		return groups
+	}
+
+	out := make([]Host, 0, len(order))
+	for _, name := range order {
+		groups := groupsOf(name)
+		vars := map[string]string{}
+		for k, v := range groupVars["all"] {
+			vars[k] = v
+		}
+		for i := len(groups) - 1; i >= 0; i-- {
+			for k, v := range groupVars[groups[i]] {
+				vars[k] = v
+			}
+		}
+		for k, v := range hosts[name].vars {
+			vars[k] = v
+		}
+
+		h := Host{
+			Name:         name,
+			Address:      firstNonEmpty(vars["ansible_host"], vars["ansible_ssh_host"], name),
+			User:         firstNonEmpty(vars["ansible_user"], vars["ansible_ssh_user"]),
+			KeyPath:      vars["ansible_ssh_private_key_file"],
+			Groups:       groups,
+			ResourceType: "host",
+			ResourceID:   name,
+		}
+		if v := firstNonEmpty(vars["ansible_port"], vars["ansible_ssh_port"]); v != "" {
+			port, err := strconv.Atoi(v)
+			if err != nil || port < 1 || port > 65535 {
+				return nil, fmt.Errorf("%s: host %s: invalid ansible_port %q", path, name, v)
+			}
+			h.Port = port
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// splitINIFields splits a host line on blanks, keeping quoted values whole
+// and dropping a trailing comment.
+func splitINIFields(line string) ([]string, error) {
+	var fields []string
+	var b strings.Builder
+	var quote byte
+	inField := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inField = c, true
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+				inField = false
+			}
+		case c == '#' && !inField:
+			i = len(line)
+		default:
+			b.WriteByte(c)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, b.String())
+	}
+	return fields, nil
+}
+
+func unquoteINI(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// expandHostPattern expands one numeric or alphabetic range such as
+// web[01:03].example.com; zero padding of the start is kept.
+func expandHostPattern(pattern string) ([]string, error) {
+	open := strings.IndexByte(pattern, '[')
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.IndexByte(pattern[open:], ']')
+	if end < 0 {
+		return nil, fmt.Errorf("invalid host range in %q", pattern)
+	}
+	end += open
+	from, to, ok := strings.Cut(pattern[open+1:end], ":")
+	if !ok || from == "" || to == "" {
+		return nil, fmt.Errorf("invalid host range in %q", pattern)
+	}
+	prefix, suffix := pattern[:open], pattern[end+1:]
+
+	var out []string
+	if a, err := strconv.Atoi(from); err == nil {
+		b, err := strconv.Atoi(to)
+		if err != nil || b < a {
+			return nil, fmt.Errorf("invalid host range in %q", pattern)
+		}
+		for i := a; i <= b; i++ {
+			out = append(out, fmt.Sprintf("%s%0*d%s", prefix, len(from), i, suffix))
+		}
+		return out, nil
+	}
+	if len(from) != 1 || len(to) != 1 || from[0] > to[0] {
+		return nil, fmt.Errorf("invalid host range in %q", pattern)
+	}
+	for c := from[0]; c <= to[0]; c++ {
+		out = append(out, prefix+string(c)+suffix)
+	}
+	return out, nil
+}
+
+// StaticHosts reads a YAML host list:
+//
+//	defaults:
+//	  user: devops
+//	  key_path: ~/.ssh/id_ed25519
+//	hosts:
+//	  - name: web-1
+//	    address: 203.0.113.10
+//	    groups: [web]
+//	  - address: 203.0.113.11
+//	    port: 2222
+//
+// Relative key paths are resolved against the file's directory. Groups
+// limits the result to hosts in any of the groups; empty means all hosts.
+type StaticHosts struct {
+	Path   string
+	Groups []string
+}
+
+type staticHost struct {
+	Name    string   `yaml:"name"`
+	Address string   `yaml:"address"`
+	User    string   `yaml:"user"`
+	Port    int      `yaml:"port"`
+	KeyPath string   `yaml:"key_path"`
+	Groups  []string `yaml:"groups"`
+}
+
+func (s StaticHosts) Hosts(ctx context.Context) ([]Host, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Defaults struct {
+			User    string `yaml:"user"`
+			Port    int    `yaml:"port"`
+			KeyPath string `yaml:"key_path"`
+		} `yaml:"defaults"`
+		Hosts []staticHost `yaml:"hosts"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", s.Path, err)
+	}
+
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) || strings.HasPrefix(p, "~") {
+			return p
+		}
+		return filepath.Join(filepath.Dir(s.Path), p)
+	}
+	seen := map[string]bool{}
+	hosts := make([]Host, 0, len(doc.Hosts))
+	for i, sh := range doc.Hosts {
+		name := firstNonEmpty(sh.Name, sh.Address)
+		if strings.TrimSpace(sh.Address) == "" {
+			return nil, fmt.Errorf("%s: hosts[%d]: address is required", s.Path, i)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s: hosts[%d]: duplicate host %q", s.Path, i, name)
+		}
+		seen[name] = true
+		port := sh.Port
+		if port == 0 {
+			port = doc.Defaults.Port
+		}
+		if port < 0 || port > 65535 {
+			return nil, fmt.Errorf("%s: host %s: invalid port %d", s.Path, name, port)
+		}
+		hosts = append(hosts, Host{
+			Name:         name,
+			Address:      sh.Address,
+			User:         firstNonEmpty(sh.User, doc.Defaults.User),
+			Port:         port,
+			KeyPath:      resolve(firstNonEmpty(sh.KeyPath, doc.Defaults.KeyPath)),
+			Groups:       sh.Groups,
+			ResourceType: "host",
+			ResourceID:   name,
+		})
+	}
+	return filterHostGroups(hosts, s.Groups)
+}
+
+func (s StaticHosts) String() string {
+	return hostSourceString(HostSourceStatic, s.Path, s.Groups)
+}
+
+func filterHostGroups(hosts []Host, groups []string) ([]Host, error) {
+	if len(groups) == 0 || slices.Contains(groups, "all") {
+		return hosts, nil
+	}
+	var out []Host
+	for _, h := range hosts {
+		if slices.ContainsFunc(h.Groups, func(g string) bool { return slices.Contains(groups, g) }) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func hostSourceString(kind string, path string, groups []string) string {
+	s := kind + " inventory " + path
+	if len(groups) > 0 {
+		s += " (groups " + strings.Join(groups, ",") + ")"
+	}
+	return s
+}
+
+// Hosts lists the hosts of the run's host source and registers their SSH
+// settings with the executor when it is the SSH runner.
+func (d Deps) Hosts(ctx context.Context) ([]Host, error) {
+	src := d.HostSource
+	if src == nil {
+		src = DOHosts{DO: d.DO, EnvTag: d.Config.EnvTag, Scope: d.Config.Scope}
+	}
+	hosts, err := src.Hosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list hosts from %s: %w", src, err)
+	}
+	if r, ok := d.Exec.(*SSHRunner); ok {
+		r.AddHosts(hosts)
+	}
+	return hosts, nil
+}
@@ -0,0 +1,286 @@
+package cisctl
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// Inventory lists the resources in scope for an env tag and which controls
+// evaluate each of them.
+type Inventory struct {
+	EnvTag      string          `json:"env_tag"`
+	Scope       *ReportScope    `json:"scope"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Items       []InventoryItem `json:"items"`
+	// BlindSpots counts items that no control evaluates.
+	BlindSpots int `json:"blind_spots"`
+	// Errors holds, per resource type, why it could not be listed.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type InventoryItem struct {
+	Type      string            `json:"type"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Region    string            `json:"region,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	CoveredBy []string          `json:"covered_by"`
+}
+
+// BuildInventory collects the in-scope resources. Droplets are selected by
+// cfg's env tag and scope; firewalls, volumes and alert policies are in scope
+// when they target one of the scope's tags or one of those droplets; volumes
+// and buckets must also match the scope's region, exclude tag and project
+// selectors. SSH keys are account-wide. Only a failure to list droplets is
+// returned as an error.
+func BuildInventory(ctx context.Context, cfg Config, do *DOClient, controls []Control) (Inventory, error) {
+	inv := Inventory{EnvTag: cfg.EnvTag, Scope: cfg.reportScope(), GeneratedAt: time.Now().UTC(), Errors: map[string]string{}}
+
+	coverage := map[string][]string{}
+	for _, c := range controls {
+		if d, ok := c.(Describer); ok {
+			for _, t := range d.Describe().ResourceTypes {
+				coverage[t] = append(coverage[t], c.ID())
+			}
+		}
+	}
+
+	droplets, err := do.ListScopedDroplets(ctx, cfg.EnvTag, cfg.Scope)
+	if err != nil {
+		return inv, err
+	}
+	names := map[int]string{}
+	for _, d := range droplets {
+		names[d.ID] = d.Name
+		privateIP, _ := d.PrivateIPv4()
+		inv.Items = append(inv.Items, InventoryItem{
+			Type:   "droplet",
+			ID:     strconv.Itoa(d.ID),
+			Name:   d.Name,
+			Region: regionSlug(d.Region),
+			Tags:   d.Tags,
+			Details: map[string]string{
+				"public_ip":  DropletPublicIPv4(d),
+				"private_ip": privateIP,
+				"vpc":        d.VPCUUID,
+				"features":   strings.Join(d.Features, ","),
+				"size":       d.SizeSlug,
+				"status":     d.Status,
+			},
+		})
+	}
+	inScope := func(ids []int, tags []string) bool {
+		if slices.ContainsFunc(inv.Scope.Tags, func(t string) bool { return HasString(tags, t) }) {
+			return true
+		}
+		return slices.ContainsFunc(ids, func(id int) bool { _, ok := names[id]; return ok })
+	}
+	dropletNames := func(ids []int) string {
+		var out []string
+		for _, id := range ids {
+			out = append(out, firstNonEmpty(names[id], strconv.Itoa(id)))
+		}
+		return strings.Join(out, ",")
+	}
+
+	if firewalls, err := do.ListFirewalls(ctx); err != nil {
+		inv.Errors["firewall"] = err.Error()
+	} else {
+		for _, fw := range firewalls {
+			if !inScope(fw.DropletIDs, fw.Tags) {
+				continue
+			}
+			inv.Items = append(inv.Items, InventoryItem{
+				Type: "firewall",
+				ID:   fw.ID,
+				Name: fw.Name,
+				Details: map[string]string{
+					"target_tags":     strings.Join(fw.Tags, ","),
+					"target_droplets": dropletNames(fw.DropletIDs),
+					"inbound_rules":   strconv.Itoa(len(fw.InboundRules)),
+					"outbound_rules":  strconv.Itoa(len(fw.OutboundRules)),
+					"status":          fw.Status,
+				},
+			})
+		}
+	}
+
+	if volumes, err := do.ListVolumes(ctx); err != nil {
+		inv.Errors["volume"] = err.Error()
+	} else {
+		for _, v := range volumes {
+			if !inScope(v.DropletIDs, v.Tags) {
+				continue
+			}
+			if ok, err := cfg.Scope.MatchVolume(ctx, do, v); err != nil {
+				inv.Errors["volume"] = err.Error()
+				break
+			} else if !ok {
+				continue
+			}
+			inv.Items = append(inv.Items, InventoryItem{
+				Type:   "volume",
+				ID:     v.ID,
+				Name:   v.Name,
+				Region: regionSlug(v.Region),
+				Tags:   v.Tags,
+				Details: map[string]string{
+					"size_gb":     strconv.FormatInt(v.SizeGigaBytes, 10),
+					"attached_to": dropletNames(v.DropletIDs),
+					"filesystem":  v.FilesystemType,
+				},
+			})
+		}
+	}
+
+	if policies, err := do.ListAlertPolicies(ctx); err != nil {
+		inv.Errors["alert_policy"] = err.Error()
+	} else {
+		for _, p := range policies {
+			var ids []int
+			for _, e := range p.Entities {
+				if id, err := strconv.Atoi(e); err == nil {
+					ids = append(ids, id)
+				}
+			}
+			if !inScope(ids, p.Tags) {
+				continue
+			}
+			inv.Items = append(inv.Items, InventoryItem{
+				Type: "alert_policy",
+				ID:   p.UUID,
+				Name: p.Description,
+				Tags: p.Tags,
+				Details: map[string]string{
+					"type":    p.Type,
+					"compare": fmt.Sprintf("%s %g for %s", p.Compare, p.Value, p.Window),
+					"enabled": yesNo(p.Enabled),
+				},
+			})
+		}
+	}
+
+	if keys, err := do.ListSSHKeys(ctx); err != nil {
+		inv.Errors["ssh_key"] = err.Error()
+	} else {
+		for _, k := range keys {
+			inv.Items = append(inv.Items, InventoryItem{
+				Type:    "ssh_key",
+				ID:      strconv.Itoa(k.ID),
+				Name:    k.Name,
+				Details: map[string]string{"fingerprint": k.Fingerprint},
+			})
+		}
+	}
+
+	if buckets, err := ListSpacesBuckets(ctx, cfg); err != nil {
+		inv.Errors["bucket"] = err.Error()
+	} else {
+		for _, b := range buckets {
+			if ok, err := cfg.Scope.MatchBucket(ctx, do, b.Name, cfg.SpacesRegion); err != nil {
+				inv.Errors["bucket"] = err.Error()
+				break
+			} else if !ok {
+				continue
+			}
+			inv.Items = append(inv.Items, InventoryItem{
+				Type:    "bucket",
+				ID:      b.Name,
+				Name:    b.Name,
+				Region:  cfg.SpacesRegion,
+				Details: map[string]string{"created": b.CreationDate.Format(time.RFC3339)},
+			})
+		}
+	}
+
+	for i := range inv.Items {
+		inv.Items[i].CoveredBy = slices.Clone(coverage[inv.Items[i].Type])
+		if inv.Items[i].CoveredBy == nil {
+			inv.Items[i].CoveredBy = []string{}
+			inv.BlindSpots++
+		}
+	}
+	if len(inv.Errors) == 0 {
+		inv.Errors = nil
+	}
+	return inv, nil
+}
+
+func regionSlug(r *godo.Region) string {
+	if r == nil {
+		return ""
+	}
+	return r.Slug
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// detailString renders details as sorted key=value pairs, skipping empty values.
+func detailString(details map[string]string, sep string) string {
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(details)) {
+		if details[k] != "" {
+			parts = append(parts, k+"="+details[k])
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func WriteInventoryTable(w io.Writer, inv Inventory) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tNAME\tID\tREGION\tCOVERED BY\tDETAILS")
+	for _, it := range inv.Items {
+		covered := strings.Join(it.CoveredBy, ",")
+		if covered == "" {
+			covered = "!! NOT EVALUATED"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", it.Type, it.Name, it.ID, it.Region, covered, detailString(it.Details, " "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	scope := inv.EnvTag
+	if inv.Scope != nil {
+		scope = inv.Scope.String()
+	}
+	fmt.Fprintf(w, "\n%d resource(s) in scope for %s; %d not evaluated by any control\n", len(inv.Items), scope, inv.BlindSpots)
+	for _, t := range slices.Sorted(maps.Keys(inv.Errors)) {
+		fmt.Fprintf(w, "Could not list %s: %s\n", t, inv.Errors[t])
+	}
+	return nil
+}
+
+func WriteInventoryCSV(w io.Writer, inv Inventory) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"type", "id", "name", "region", "tags", "covered_by", "details"})
+	for _, it := range inv.Items {
+		_ = cw.Write([]string{
+			it.Type,
+			it.ID,
+			it.Name,
+			it.Region,
+			strings.Join(it.Tags, ";"),
+			strings.Join(it.CoveredBy, ";"),
+			detailString(it.Details, ";"),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,42 +1,192 @@
 package cisctl
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+type LogOptions struct {
+	Dir    string
+	Format string
+	Level  slog.Level
+	RunID  string
+}
+
+// Logger writes structured records to a per-control file and, for control
+// loggers, to the run-wide combined log as well.
 type Logger struct {
-	l *log.Logger
+	l    *slog.Logger
+	opts LogOptions
+	run  slog.Handler
+	file *os.File
 }
 
-func NewControlLogger(logDir string, controlID string, t time.Time) (*Logger, string, error) {
-	if err := os.MkdirAll(logDir, 0o755); err != nil {
+// NewRunLogger opens the run-wide combined log that every control logger
+// derived from it also writes to.
+func NewRunLogger(opts LogOptions, t time.Time) (*Logger, string, error) {
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
 		return nil, "", err
 	}
-	path := filepath.Join(logDir, fmt.Sprintf("cisctl_%s_%s.log", sanitize(controlID), t.Format("20060102150405")))
+	path := filepath.Join(opts.Dir, fmt.Sprintf("cisctl_run_%s.log", t.Format("20060102150405")))
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
 		return nil, "", err
 	}
-	l := log.New(f, "", log.LstdFlags|log.LUTC)
-	return &Logger{l: l}, path, nil
+	h := newLogHandler(f, opts).WithAttrs([]slog.Attr{slog.String("run_id", opts.RunID)})
+	return &Logger{l: slog.New(h), opts: opts, run: h, file: f}, path, nil
 }
 
-func (l *Logger) Infof(format string, args ...any) {
+// NewStreamLogger writes to w instead of a file, for long-running commands
+// such as serve.
+func NewStreamLogger(w io.Writer, opts LogOptions) *Logger {
+	return &Logger{l: slog.New(newLogHandler(w, opts)), opts: opts}
+}
+
+// NewControlLogger opens the log file for one control.
+func (l *Logger) NewControlLogger(controlID string, t time.Time) (*Logger, string, error) {
+	if l == nil {
+		return nil, "", errors.New("run logger is nil")
+	}
+	path := filepath.Join(l.opts.Dir, fmt.Sprintf("cisctl_%s_%s.log", sanitize(controlID), t.Format("20060102150405")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, "", err
+	}
+	attrs := []slog.Attr{slog.String("run_id", l.opts.RunID)}
+	var h slog.Handler = newLogHandler(f, l.opts).WithAttrs(attrs)
+	if l.run != nil {
+		h = multiHandler{h, l.run}
+	}
+	h = h.WithAttrs([]slog.Attr{slog.String("control_id", controlID)})
+	return &Logger{l: slog.New(h), opts: l.opts, file: f}, path, nil
+}
+
+func (l *Logger) Close() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// With returns a logger that adds args as attributes to every record.
+func (l *Logger) With(args ...any) *Logger {
 	if l == nil || l.l == nil {
-		return
+		return l
+	}
+	return &Logger{l: l.l.With(args...), opts: l.opts}
+}
+
+// WithFinding attaches the resource identifiers of f to every record.
+func (l *Logger) WithFinding(f Finding) *Logger {
+	args := []any{slog.String("resource_type", f.ResourceType)}
+	if f.ResourceID != "" {
+		args = append(args, slog.String("resource_id", f.ResourceID))
+	}
+	if f.ResourceName != "" {
+		args = append(args, slog.String("resource_name", f.ResourceName))
 	}
-	l.l.Printf("[INFO] "+format, args...)
+	if f.IP != "" {
+		args = append(args, slog.String("ip", f.IP))
+	}
+	return l.With(args...)
 }
 
-func (l *Logger) Errorf(format string, args ...any) {
+func (l *Logger) Debug(msg string, args ...any) { l.log(slog.LevelDebug, msg, args...) }
+func (l *Logger) Info(msg string, args ...any)  { l.log(slog.LevelInfo, msg, args...) }
+func (l *Logger) Warn(msg string, args ...any)  { l.log(slog.LevelWarn, msg, args...) }
+func (l *Logger) Error(msg string, args ...any) { l.log(slog.LevelError, msg, args...) }
+
+func (l *Logger) log(level slog.Level, msg string, args ...any) {
 	if l == nil || l.l == nil {
 		return
 	}
-	l.l.Printf("[ERROR] "+format, args...)
+	l.l.Log(context.Background(), level, msg, args...)
+}
+
+// ParseLogLevel accepts debug, info, warn or error.
+func ParseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// NewRunID returns a random identifier used to correlate logs and reports of one run.
+func NewRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func newLogHandler(w io.Writer, opts LogOptions) slog.Handler {
+	ho := &slog.HandlerOptions{
+		Level: opts.Level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				a.Value = slog.TimeValue(a.Value.Time().UTC())
+			}
+			return a
+		},
+	}
+	if opts.Format == LogFormatJSON {
+		return slog.NewJSONHandler(w, ho)
+	}
+	return slog.NewTextHandler(w, ho)
+}
+
+// multiHandler fans a record out to several handlers.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithGroup(name)
+	}
+	return out
 }
 
 func sanitize(s string) string {
@@ -57,4 +207,3 @@ func sanitize(s string) string {
 	}
 	return string(out)
 }
-
@@ -0,0 +1,124 @@
+package cisctl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TextfileName is the file written into a node_exporter textfile collector directory.
+const TextfileName = "cisctl.prom"
+
+// WritePrometheus writes the report as Prometheus text exposition format.
+func WritePrometheus(w io.Writer, report Report) error {
+	var b strings.Builder
+
+	b.WriteString("# HELP cisctl_control_pass Whether the control passed (1) or failed (0).\n")
+	b.WriteString("# TYPE cisctl_control_pass gauge\n")
+	for _, r := range report.Results {
+		fmt.Fprintf(&b, "cisctl_control_pass{control_id=%s,title=%s} %d\n",
+			escapeLabel(r.ControlID), escapeLabel(r.Title), boolGauge(r.Pass))
+	}
+
+	b.WriteString("# HELP cisctl_finding_fail Whether the finding failed (1) or passed (0).\n")
+	b.WriteString("# TYPE cisctl_finding_fail gauge\n")
+	for _, s := range findingSeries(report.Results) {
+		fmt.Fprintf(&b, "cisctl_finding_fail{control_id=%s,resource_type=%s,resource_name=%s} %d\n",
+			escapeLabel(s.controlID), escapeLabel(s.resourceType), escapeLabel(s.resourceName), s.value)
+	}
+
+	b.WriteString("# HELP cisctl_control_duration_seconds Time spent running the control.\n")
+	b.WriteString("# TYPE cisctl_control_duration_seconds gauge\n")
+	for _, r := range report.Results {
+		fmt.Fprintf(&b, "cisctl_control_duration_seconds{control_id=%s} %g\n",
+			escapeLabel(r.ControlID), r.FinishedAt.Sub(r.StartedAt).Seconds())
+	}
+
+	b.WriteString("# HELP cisctl_last_run_timestamp Unix time of the last cisctl run.\n")
+	b.WriteString("# TYPE cisctl_last_run_timestamp gauge\n")
+	fmt.Fprintf(&b, "cisctl_last_run_timestamp %d\n", report.Timestamp.Unix())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteTextfile writes the metrics into dir for the node_exporter textfile
+// collector. The file is renamed into place so the collector never reads a
+// partially written file.
+func WriteTextfile(dir string, report Report) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, TextfileName)
+	return path, writeFileAtomic(path, func(w io.Writer) error {
+		return WritePrometheus(w, report)
+	})
+}
+
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type findingSample struct {
+	controlID    string
+	resourceType string
+	resourceName string
+	value        int
+}
+
+// findingSeries collapses findings that share a label set (e.g. several
+// control-level findings without a resource name) so every series is unique.
+func findingSeries(results []ControlResult) []findingSample {
+	var out []findingSample
+	index := map[[3]string]int{}
+	for _, r := range results {
+		for _, f := range r.Findings {
+			key := [3]string{r.ControlID, f.ResourceType, f.ResourceName}
+			v := boolGauge(f.Failing())
+			if i, ok := index[key]; ok {
+				out[i].value = max(out[i].value, v)
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, findingSample{
+				controlID:    r.ControlID,
+				resourceType: f.ResourceType,
+				resourceName: f.ResourceName,
+				value:        v,
+			})
+		}
+	}
+	return out
+}
+
+func boolGauge(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel quotes a label value as the exposition format expects.
+func escapeLabel(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,311 @@
+package cisctl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the cisctl.yaml document: a set of named profiles and the one
+// used when none is selected.
+type ConfigFile struct {
+	Path           string             `yaml:"-"`
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of one environment. Empty fields keep the
+// built-in defaults; environment variables and flags override them. Relative
+// paths are resolved against the deployment root. Profiles never hold the DO
+// token itself, only where to read it from.
+type Profile struct {
+	EnvTag    string `yaml:"env_tag"`
+	LogDir    string `yaml:"log_dir"`
+	LogLevel  string `yaml:"log_level"`
+	LogFormat string `yaml:"log_format"`
+	ReportDir string `yaml:"report_dir"`
+
+	// At most one of TokenFile, TokenCommand and DoctlContext may be set.
+	TokenFile    string `yaml:"token_file"`
+	TokenCommand string `yaml:"token_command"`
+	DoctlContext string `yaml:"doctl_context"`
+
+	SSH struct {
+		User         string        `yaml:"user"`
+		UserFallback string        `yaml:"user_fallback"`
+		KeyPath      string        `yaml:"key_path"`
+		Port         int           `yaml:"port"`
+		Timeout      time.Duration `yaml:"timeout"`
+	} `yaml:"ssh"`
+
+	Scope struct {
+		IncludeTags []string `yaml:"include_tags"`
+		ExcludeTags []string `yaml:"exclude_tags"`
+		NameRegex   string   `yaml:"name_regex"`
+		Regions     []string `yaml:"regions"`
+		Project     string   `yaml:"project"`
+	} `yaml:"scope"`
+
+	// Hosts selects the hosts of the SSH-based controls, like --hosts and
+	// --host-groups. Inventory paths are relative to the deployment root.
+	Hosts struct {
+		Source string   `yaml:"source"`
+		Groups []string `yaml:"groups"`
+	} `yaml:"hosts"`
+
+	// Controls limits which controls run, like --controls.
+	Controls []string `yaml:"controls"`
+	// ControlParams holds per-control parameters keyed by control ID.
+	ControlParams map[string]map[string]string `yaml:"control_params"`
+	// LocalTimeout bounds each host command of local runs; 0 means no limit.
+	LocalTimeout time.Duration `yaml:"local_timeout"`
+	// ControlsDir holds YAML host-check controls, like --controls-dir.
+	ControlsDir string `yaml:"controls_dir"`
+	// ExecControls lists external control executables, like
+	// --exec-controls; ExecTimeout bounds each run.
+	ExecControls []string      `yaml:"exec_controls"`
+	ExecTimeout  time.Duration `yaml:"exec_timeout"`
+	// ExecSkipDefined skips exec controls whose ID is taken, like
+	// --exec-skip-defined.
+	ExecSkipDefined bool `yaml:"exec_skip_defined"`
+
+	RemediateFirewall string   `yaml:"remediate_firewall"`
+	AlertEmails       []string `yaml:"alert_emails"`
+	WaiversFile       string   `yaml:"waivers_file"`
+
+	Spaces struct {
+		Endpoint string `yaml:"endpoint"`
+		Region   string `yaml:"region"`
+	} `yaml:"spaces"`
+
+	Notify struct {
+		SlackWebhookURL string   `yaml:"slack_webhook_url"`
+		RunURL          string   `yaml:"run_url"`
+		WebhookURLs     []string `yaml:"webhook_urls"`
+		WebhookSecret   string   `yaml:"webhook_secret"`
+	} `yaml:"notify"`
+}
+
+// LoadConfigFile reads and validates a cisctl.yaml file:
+//
+//	default_profile: demo
+//	profiles:
+//	  demo:
+//	    env_tag: env:demo
+//	    ssh:
+//	      user: devops
+//	  prod:
+//	    env_tag: env:prod
+//	    controls: ["2.1.1", "2.1.2"]
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &ConfigFile{Path: path}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if file.DefaultProfile != "" {
+		if _, ok := file.Profiles[file.DefaultProfile]; !ok {
+			return nil, fmt.Errorf("%s: default_profile %q is not defined", path, file.DefaultProfile)
+		}
+	}
+	for name, p := range file.Profiles {
+		if p.LogLevel != "" {
+			if _, err := ParseLogLevel(p.LogLevel); err != nil {
+				return nil, fmt.Errorf("%s: profile %q: %w", path, name, err)
+			}
+		}
+		sources := 0
+		for _, v := range []string{p.TokenFile, p.TokenCommand, p.DoctlContext} {
+			if v != "" {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return nil, fmt.Errorf("%s: profile %q: set only one of token_file, token_command and doctl_context", path, name)
+		}
+		if _, _, err := parseHostsSource(p.Hosts.Source); err != nil {
+			return nil, fmt.Errorf("%s: profile %q: hosts.source: %w", path, name, err)
+		}
+		if p.SSH.Port < 0 || p.SSH.Port > 65535 {
+			return nil, fmt.Errorf("%s: profile %q: invalid ssh.port %d", path, name, p.SSH.Port)
+		}
+	}
+	return file, nil
+}
+
+// Profile returns the named profile, or the default profile when name is
+// empty. It returns nil when no profile is selected.
+func (f *ConfigFile) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = f.DefaultProfile
+	}
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown profile %q (have: %s)", f.Path, name,
+			strings.Join(slices.Sorted(maps.Keys(f.Profiles)), ", "))
+	}
+	return &p, nil
+}
+
+// apply copies the profile's settings over cfg.
+func (p *Profile) apply(cfg *Config) error {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "~") {
+			return path
+		}
+		return filepath.Join(cfg.RootDir, path)
+	}
+
+	if p.EnvTag != "" {
+		cfg.EnvTag = p.EnvTag
+	}
+	if p.LogDir != "" {
+		cfg.LogDir = resolve(p.LogDir)
+	}
+	if p.LogLevel != "" {
+		level, err := ParseLogLevel(p.LogLevel)
+		if err != nil {
+			return err
+		}
+		cfg.LogLevel = level
+	}
+	if p.LogFormat != "" {
+		cfg.LogFormat = p.LogFormat
+	}
+	if p.ReportDir != "" {
+		cfg.ReportDir = resolve(p.ReportDir)
+	}
+
+	// A token source replaces a token from the .env file.
+	switch {
+	case p.TokenFile != "":
+		cfg.DOAccessToken = ""
+		cfg.TokenSource = TokenSource{Kind: TokenSourceFile, Ref: resolve(p.TokenFile)}
+	case p.TokenCommand != "":
+		cfg.DOAccessToken = ""
+		cfg.TokenSource = TokenSource{Kind: TokenSourceCommand, Ref: p.TokenCommand}
+	case p.DoctlContext != "":
+		cfg.DOAccessToken = ""
+		cfg.TokenSource = TokenSource{Kind: TokenSourceDoctl, Ref: p.DoctlContext}
+	}
+
+	if p.SSH.User != "" {
+		cfg.SSHUser = p.SSH.User
+	}
+	if p.SSH.UserFallback != "" {
+		cfg.SSHUserFallback = p.SSH.UserFallback
+	}
+	if p.SSH.KeyPath != "" {
+		cfg.SSHKeyPath = resolve(p.SSH.KeyPath)
+	}
+	if p.SSH.Port > 0 {
+		cfg.SSHPort = p.SSH.Port
+	}
+	if p.SSH.Timeout > 0 {
+		cfg.SSHTimeout = p.SSH.Timeout
+	}
+
+	if len(p.Scope.IncludeTags) > 0 {
+		cfg.Scope.IncludeTags = slices.Clone(p.Scope.IncludeTags)
+	}
+	if len(p.Scope.ExcludeTags) > 0 {
+		cfg.Scope.ExcludeTags = slices.Clone(p.Scope.ExcludeTags)
+	}
+	if p.Scope.NameRegex != "" {
+		cfg.Scope.NameRegex = p.Scope.NameRegex
+	}
+	if len(p.Scope.Regions) > 0 {
+		cfg.Scope.Regions = slices.Clone(p.Scope.Regions)
+	}
+	if p.Scope.Project != "" {
+		cfg.Scope.Project = p.Scope.Project
+	}
+
+	if p.Hosts.Source != "" {
+		kind, path, err := parseHostsSource(p.Hosts.Source)
+		if err != nil {
+			return err
+		}
+		cfg.HostsSource = kind
+		if path != "" {
+			cfg.HostsSource = kind + ":" + resolve(path)
+		}
+	}
+	if len(p.Hosts.Groups) > 0 {
+		cfg.HostGroups = slices.Clone(p.Hosts.Groups)
+	}
+
+	if len(p.Controls) > 0 {
+		cfg.Controls = slices.Clone(p.Controls)
+	}
+	if len(p.ControlParams) > 0 {
+		cfg.ControlParams = map[string]map[string]string{}
+		for id, params := range p.ControlParams {
+			cfg.ControlParams[id] = maps.Clone(params)
+		}
+	}
+	if p.LocalTimeout > 0 {
+		cfg.LocalTimeout = p.LocalTimeout
+	}
+	if p.ControlsDir != "" {
+		cfg.ControlsDir = resolve(p.ControlsDir)
+	}
+	if len(p.ExecControls) > 0 {
+		cfg.ExecControls = slices.Clone(p.ExecControls)
+	}
+	if p.ExecTimeout > 0 {
+		cfg.ExecTimeout = p.ExecTimeout
+	}
+	if p.ExecSkipDefined {
+		cfg.ExecSkipDefined = true
+	}
+
+	if p.RemediateFirewall != "" {
+		cfg.RemediateFirewall = p.RemediateFirewall
+	}
+	if len(p.AlertEmails) > 0 {
+		cfg.AlertEmails = slices.Clone(p.AlertEmails)
+	}
+	if p.WaiversFile != "" {
+		cfg.WaiversFile = resolve(p.WaiversFile)
+	}
+
+	if p.Spaces.Region != "" {
+		cfg.SpacesRegion = p.Spaces.Region
+	}
+	if p.Spaces.Endpoint != "" {
+		cfg.SpacesEndpoint = p.Spaces.Endpoint
+	}
+
+	if p.Notify.SlackWebhookURL != "" {
+		cfg.Notify.SlackWebhookURL = p.Notify.SlackWebhookURL
+	}
+	if p.Notify.RunURL != "" {
+		cfg.Notify.RunURL = p.Notify.RunURL
+	}
+	if len(p.Notify.WebhookURLs) > 0 {
+		cfg.Notify.WebhookURLs = slices.Clone(p.Notify.WebhookURLs)
+	}
+	if p.Notify.WebhookSecret != "" {
+		cfg.Notify.WebhookSecret = p.Notify.WebhookSecret
+	}
+	return nil
+}
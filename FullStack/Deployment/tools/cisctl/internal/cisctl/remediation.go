@@ -0,0 +1,24 @@
+package cisctl
+
+import "context"
+
+// Remediator is implemented by controls that can plan fixes for their own
+// failed findings.
+type Remediator interface {
+	Control
+	PlanRemediation(ctx context.Context, deps Deps, result ControlResult) ([]RemediationStep, error)
+}
+
+// RemediationStep is one change proposed by a Remediator. Steps with the same
+// Key are applied once even when several controls propose them. Steps
+// without Apply cannot be automated and are only printed in the plan.
+type RemediationStep struct {
+	Key      string
+	Resource string
+	Action   string
+	Apply    func(ctx context.Context) error
+}
+
+func (s RemediationStep) Manual() bool {
+	return s.Apply == nil
+}
@@ -11,24 +11,41 @@ type ToolInfo struct {
 	Version string `json:"version"`
 }
 
+// Control statuses. A control is WAIVED when every failing finding is
+// covered by an unexpired waiver, and NOT_APPLICABLE when it does not apply to
+// any resource it found; both still count as passing.
+const (
+	StatusPass          = "PASS"
+	StatusFail          = "FAIL"
+	StatusWaived        = "WAIVED"
+	StatusNotApplicable = "NOT_APPLICABLE"
+)
+
 type Report struct {
-	Timestamp time.Time       `json:"timestamp"`
-	EnvTag    string          `json:"env_tag"`
-	RootDir   string          `json:"root_dir"`
-	Tool      ToolInfo        `json:"tool"`
-	Summary   Summary         `json:"summary"`
-	Results   []ControlResult `json:"results"`
+	SchemaVersion int             `json:"schema_version"`
+	RunID         string          `json:"run_id,omitempty"`
+	Timestamp     time.Time       `json:"timestamp"`
+	EnvTag        string          `json:"env_tag"`
+	RootDir       string          `json:"root_dir"`
+	WaiversFile   string          `json:"waivers_file,omitempty"`
+	Scope         *ReportScope    `json:"scope,omitempty"`
+	Tool          ToolInfo        `json:"tool"`
+	Summary       Summary         `json:"summary"`
+	Results       []ControlResult `json:"results"`
 }
 
 type Summary struct {
-	Total int `json:"total"`
-	Pass  int `json:"pass"`
-	Fail  int `json:"fail"`
+	Total         int `json:"total"`
+	Pass          int `json:"pass"`
+	Fail          int `json:"fail"`
+	Waived        int `json:"waived"`
+	NotApplicable int `json:"not_applicable"`
 }
 
 type ControlResult struct {
 	ControlID  string    `json:"control_id"`
 	Title      string    `json:"title"`
+	Status     string    `json:"status"`
 	Pass       bool      `json:"pass"`
 	Error      string    `json:"error,omitempty"`
 	Notes      string    `json:"notes,omitempty"`
@@ -46,15 +63,54 @@ type Finding struct {
 	Pass         bool              `json:"pass"`
 	Reason       string            `json:"reason,omitempty"`
 	Evidence     map[string]string `json:"evidence,omitempty"`
+	Waiver       *FindingWaiver    `json:"waiver,omitempty"`
+	// NotApplicable marks a resource the check does not apply to, such as a
+	// host on an unsupported distribution. Such findings pass.
+	NotApplicable bool `json:"not_applicable,omitempty"`
+}
+
+// FindingWaiver records the waiver that matched a failing finding.
+type FindingWaiver struct {
+	Justification string `json:"justification"`
+	Approver      string `json:"approver"`
+	Expires       string `json:"expires"`
+	Expired       bool   `json:"expired,omitempty"`
+}
+
+// Failing reports whether f fails and is not covered by an unexpired waiver.
+func (f Finding) Failing() bool {
+	return !f.Pass && (f.Waiver == nil || f.Waiver.Expired)
+}
+
+// NewReport returns an empty report for cfg, stamped with a new run ID.
+func NewReport(cfg Config, startedAt time.Time) Report {
+	return Report{
+		SchemaVersion: ReportSchemaVersion,
+		RunID:         NewRunID(),
+		Timestamp:     startedAt,
+		EnvTag:        cfg.EnvTag,
+		RootDir:       cfg.RootDir,
+		WaiversFile:   cfg.WaiversFile,
+		Scope:         cfg.reportScope(),
+		Tool: ToolInfo{
+			Name:    "cisctl",
+			Version: "0.1.0",
+		},
+	}
 }
 
 func Summarize(results []ControlResult) Summary {
 	s := Summary{Total: len(results)}
 	for _, r := range results {
-		if r.Pass {
-			s.Pass++
-		} else {
+		switch {
+		case !r.Pass:
 			s.Fail++
+		case r.Status == StatusWaived:
+			s.Waived++
+		case r.Status == StatusNotApplicable:
+			s.NotApplicable++
+		default:
+			s.Pass++
 		}
 	}
 	return s
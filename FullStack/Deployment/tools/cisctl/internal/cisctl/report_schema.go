@@ -0,0 +1,160 @@
+package cisctl
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ReportSchemaVersion is the schema_version written into new reports.
+// Bump it together with report.schema.json and add a migration below.
+const ReportSchemaVersion = 3
+
+// reportSchemaURL is the $id of report.schema.json, which names its version.
+var reportSchemaURL = fmt.Sprintf("urn:cisctl:report:%d", ReportSchemaVersion)
+
+//go:embed report.schema.json
+var reportSchemaJSON []byte
+
+// reportMigrations upgrade a decoded report from the keyed version to the next one.
+var reportMigrations = map[int]func(doc map[string]any) error{
+	// Version 0 is every report written before schema_version existed. Its
+	// fields are a subset of version 1, so only the version needs setting.
+	0: func(doc map[string]any) error {
+		doc["schema_version"] = 1
+		return nil
+	},
+	// Version 2 added waivers: every result carries a status and the summary
+	// counts waived controls. Version 1 reports have no waivers.
+	1: func(doc map[string]any) error {
+		results, _ := doc["results"].([]any)
+		for _, r := range results {
+			res, ok := r.(map[string]any)
+			if !ok {
+				continue
+			}
+			if pass, _ := res["pass"].(bool); pass {
+				res["status"] = StatusPass
+			} else {
+				res["status"] = StatusFail
+			}
+		}
+		if summary, ok := doc["summary"].(map[string]any); ok {
+			summary["waived"] = 0
+		}
+		doc["schema_version"] = 2
+		return nil
+	},
+	// Version 3 added NOT_APPLICABLE results and findings, counted in the
+	// summary. Older reports have none.
+	2: func(doc map[string]any) error {
+		if summary, ok := doc["summary"].(map[string]any); ok {
+			summary["not_applicable"] = 0
+		}
+		doc["schema_version"] = 3
+		return nil
+	},
+}
+
+func ReportSchema() []byte {
+	return reportSchemaJSON
+}
+
+// ReadReport loads a report file, migrating older schema versions to the current one.
+func ReadReport(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	doc, _, err := decodeAndMigrate(data)
+	if err != nil {
+		return Report{}, fmt.Errorf("%s: %w", path, err)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return Report{}, err
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Report{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// ValidateReport checks data against the embedded schema after migrating it.
+// It returns the schema version the document was written with.
+func ValidateReport(data []byte) (int, error) {
+	doc, from, err := decodeAndMigrate(data)
+	if err != nil {
+		return from, err
+	}
+	schema, err := compileReportSchema()
+	if err != nil {
+		return from, err
+	}
+	return from, schema.Validate(doc)
+}
+
+func compileReportSchema() (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat = true
+	if err := c.AddResource(reportSchemaURL, bytes.NewReader(reportSchemaJSON)); err != nil {
+		return nil, err
+	}
+	return c.Compile(reportSchemaURL)
+}
+
+func decodeAndMigrate(data []byte) (map[string]any, int, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, 0, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if doc == nil {
+		return nil, 0, fmt.Errorf("report must be a JSON object")
+	}
+
+	from := 0
+	if v, ok := doc["schema_version"]; ok {
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, 0, fmt.Errorf("schema_version must be an integer")
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, 0, fmt.Errorf("schema_version must be an integer")
+		}
+		from = int(i)
+	}
+	if from > ReportSchemaVersion {
+		return nil, from, fmt.Errorf("schema_version %d is newer than supported version %d", from, ReportSchemaVersion)
+	}
+
+	for v := from; v < ReportSchemaVersion; v++ {
+		migrate, ok := reportMigrations[v]
+		if !ok {
+			return nil, from, fmt.Errorf("no migration from schema_version %d", v)
+		}
+		if err := migrate(doc); err != nil {
+			return nil, from, fmt.Errorf("migrate schema_version %d: %w", v, err)
+		}
+	}
+	// Round-trip through JSON so values set by migrations have the types the
+	// validator and json.Unmarshal expect.
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, from, err
+	}
+	doc = nil
+	dec = json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, from, err
+	}
+	return doc, from, nil
+}
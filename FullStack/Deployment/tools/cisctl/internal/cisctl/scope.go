@@ -0,0 +1,220 @@
+package cisctl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/godo"
+)
+
+// Scope narrows a scan beyond the env tag. Droplets are in scope when they
+// carry the env tag or one of IncludeTags, none of ExcludeTags, a name that
+// matches NameRegex, one of Regions and belong to Project; unset selectors
+// match everything. Volumes, firewalls and buckets follow the same selectors
+// where they apply to them.
+type Scope struct {
+	IncludeTags []string
+	ExcludeTags []string
+	NameRegex   string
+	Regions     []string
+	// Project is a DO project name or ID.
+	Project string
+
+	nameRE  *regexp.Regexp
+	members *projectMembers
+}
+
+// projectMembers caches the resources of Scope.Project. It is shared by the
+// copies of a Config so a run lists the project once.
+type projectMembers struct {
+	once sync.Once
+	urns map[string]bool
+	err  error
+}
+
+// compile validates the selectors; it must be called after they change.
+func (s *Scope) compile() error {
+	s.nameRE = nil
+	if s.NameRegex != "" {
+		re, err := regexp.Compile(s.NameRegex)
+		if err != nil {
+			return fmt.Errorf("invalid name regex: %w", err)
+		}
+		s.nameRE = re
+	}
+	s.members = &projectMembers{}
+	return nil
+}
+
+// EnvTagOnly reports whether no selector is set, so the env tag alone reaches
+// exactly the in-scope droplets.
+func (s Scope) EnvTagOnly() bool {
+	return len(s.IncludeTags) == 0 && len(s.ExcludeTags) == 0 && s.NameRegex == "" && len(s.Regions) == 0 && s.Project == ""
+}
+
+// Tags returns the env tag followed by the include tags.
+func (s Scope) Tags(envTag string) []string {
+	tags := []string{envTag}
+	for _, t := range s.IncludeTags {
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func (s Scope) excluded(tags []string) bool {
+	return slices.ContainsFunc(tags, func(t string) bool { return HasString(s.ExcludeTags, t) })
+}
+
+func (s Scope) inRegions(region string) bool {
+	return len(s.Regions) == 0 || HasString(s.Regions, region)
+}
+
+// inProject reports whether the resource urn belongs to the project, listing
+// the project's resources on first use.
+func (s Scope) inProject(ctx context.Context, do *DOClient, urn string) (bool, error) {
+	if s.Project == "" {
+		return true, nil
+	}
+	m := s.members
+	if m == nil {
+		m = &projectMembers{}
+	}
+	m.once.Do(func() {
+		urns, err := do.ListProjectResources(ctx, s.Project)
+		if err != nil {
+			m.err = fmt.Errorf("list resources of project %s: %w", s.Project, err)
+			return
+		}
+		m.urns = map[string]bool{}
+		for _, u := range urns {
+			m.urns[u] = true
+		}
+	})
+	return m.urns[urn], m.err
+}
+
+// MatchDroplet applies the selectors other than the include tags to d.
+func (s Scope) MatchDroplet(ctx context.Context, do *DOClient, d godo.Droplet) (bool, error) {
+	if s.excluded(d.Tags) || !s.inRegions(regionSlug(d.Region)) {
+		return false, nil
+	}
+	if s.nameRE != nil && !s.nameRE.MatchString(d.Name) {
+		return false, nil
+	}
+	return s.inProject(ctx, do, "do:droplet:"+strconv.Itoa(d.ID))
+}
+
+// MatchVolume applies the exclude tag, region and project selectors to v.
+func (s Scope) MatchVolume(ctx context.Context, do *DOClient, v godo.Volume) (bool, error) {
+	if s.excluded(v.Tags) || !s.inRegions(regionSlug(v.Region)) {
+		return false, nil
+	}
+	return s.inProject(ctx, do, "do:volume:"+v.ID)
+}
+
+// MatchBucket applies the region and project selectors to a bucket in the
+// given Spaces region.
+func (s Scope) MatchBucket(ctx context.Context, do *DOClient, name string, region string) (bool, error) {
+	if !s.inRegions(region) {
+		return false, nil
+	}
+	return s.inProject(ctx, do, "do:space:"+name)
+}
+
+// ListScopedDroplets lists the droplets carrying any of the scope's tags and
+// keeps those the other selectors match.
+func (c *DOClient) ListScopedDroplets(ctx context.Context, envTag string, scope Scope) ([]godo.Droplet, error) {
+	seen := map[int]bool{}
+	var out []godo.Droplet
+	for _, tag := range scope.Tags(envTag) {
+		droplets, err := c.ListDropletsByTag(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range droplets {
+			if seen[d.ID] {
+				continue
+			}
+			seen[d.ID] = true
+			ok, err := scope.MatchDroplet(ctx, c, d)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out = append(out, d)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Droplets lists the droplets in the run's scope.
+func (d Deps) Droplets(ctx context.Context) ([]godo.Droplet, error) {
+	return d.DO.ListScopedDroplets(ctx, d.Config.EnvTag, d.Config.Scope)
+}
+
+// ScopeString describes the run's scope for findings and messages.
+func (c Config) ScopeString() string {
+	return c.Scope.report(c.EnvTag).String()
+}
+
+// ReportScope is the effective scope recorded in a report.
+type ReportScope struct {
+	Tags        []string `json:"tags" yaml:"tags"`
+	ExcludeTags []string `json:"exclude_tags,omitempty" yaml:"exclude_tags,omitempty"`
+	NameRegex   string   `json:"name_regex,omitempty" yaml:"name_regex,omitempty"`
+	Regions     []string `json:"regions,omitempty" yaml:"regions,omitempty"`
+	Project     string   `json:"project,omitempty" yaml:"project,omitempty"`
+	// Hosts names the host source when host controls do not run against
+	// the scoped droplets.
+	Hosts string `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+}
+
+func (s Scope) report(envTag string) ReportScope {
+	return ReportScope{
+		Tags:        s.Tags(envTag),
+		ExcludeTags: s.ExcludeTags,
+		NameRegex:   s.NameRegex,
+		Regions:     s.Regions,
+		Project:     s.Project,
+	}
+}
+
+func (c Config) reportScope() *ReportScope {
+	r := c.Scope.report(c.EnvTag)
+	if c.Local {
+		r.Hosts = LocalHost{}.String()
+	} else if kind, path, err := parseHostsSource(c.HostsSource); err == nil && kind != HostSourceDO {
+		r.Hosts = hostSourceString(kind, path, c.HostGroups)
+	}
+	return &r
+}
+
+// String renders the scope, e.g. "tag env:prod in sgp1 excluding tag
+// role:bastion".
+func (r ReportScope) String() string {
+	parts := []string{"tag " + strings.Join(r.Tags, " or ")}
+	if r.NameRegex != "" {
+		parts = append(parts, fmt.Sprintf("name ~ %q", r.NameRegex))
+	}
+	if len(r.Regions) > 0 {
+		parts = append(parts, "in "+strings.Join(r.Regions, ","))
+	}
+	if r.Project != "" {
+		parts = append(parts, "in project "+r.Project)
+	}
+	if len(r.ExcludeTags) > 0 {
+		parts = append(parts, "excluding tag "+strings.Join(r.ExcludeTags, ","))
+	}
+	if r.Hosts != "" {
+		parts = append(parts, "hosts from "+r.Hosts)
+	}
+	return strings.Join(parts, " ")
+}
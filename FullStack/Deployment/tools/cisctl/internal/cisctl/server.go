@@ -0,0 +1,407 @@
+package cisctl
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RunQueued    = "queued"
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunError     = "error"
+
+	runRecordFile = "run.json"
+)
+
+// RunRecord is the state of a run submitted to `cisctl serve`. It is stored as
+// <ReportDir>/runs/<id>/run.json next to the run's report.
+type RunRecord struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	EnvTag     string     `json:"env_tag"`
+	Controls   []string   `json:"controls"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Summary    *Summary   `json:"summary,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ReportPath string     `json:"report_path,omitempty"`
+	LogPath    string     `json:"log_path,omitempty"`
+}
+
+type runJob struct {
+	id       string
+	cfg      Config
+	controls []Control
+}
+
+// Server queues runs submitted over HTTP and executes them with at most
+// concurrency runs in flight.
+type Server struct {
+	app   *App
+	cfg   Config
+	token string
+	log   *Logger
+	// concurrency is the number of workers started by Start.
+	concurrency int
+
+	mu    sync.Mutex
+	runs  map[string]*RunRecord
+	queue chan runJob
+	wg    sync.WaitGroup
+}
+
+type ServerOptions struct {
+	Concurrency int
+	QueueSize   int
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	Token string
+}
+
+// NewServer loads the runs persisted under cfg.ReportDir. Runs that were
+// queued or running when the previous server stopped are marked as errors.
+func NewServer(app *App, cfg Config, log *Logger, opts ServerOptions) (*Server, error) {
+	s := &Server{
+		app:         app,
+		cfg:         cfg,
+		token:       opts.Token,
+		log:         log,
+		concurrency: max(opts.Concurrency, 1),
+		runs:        map[string]*RunRecord{},
+		queue:       make(chan runJob, max(opts.QueueSize, 1)),
+	}
+
+	matches, err := filepath.Glob(filepath.Join(s.runsDir(), "*", runRecordFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		data, err := os.ReadFile(m)
+		if err != nil {
+			return nil, err
+		}
+		var rec RunRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			log.Warn("skipping unreadable run record", "path", m, "error", err.Error())
+			continue
+		}
+		if rec.Status == RunQueued || rec.Status == RunRunning {
+			rec.Status = RunError
+			rec.Error = "interrupted: server stopped before the run finished"
+			if err := s.persist(&rec); err != nil {
+				return nil, err
+			}
+		}
+		s.runs[rec.ID] = &rec
+	}
+	return s, nil
+}
+
+func (s *Server) runsDir() string {
+	return filepath.Join(s.cfg.ReportDir, "runs")
+}
+
+// Start launches the workers; they exit when ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	for range s.concurrency {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.queue:
+					s.execute(ctx, job)
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until the workers started by Start have exited.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /controls", s.handleControls)
+	mux.HandleFunc("GET /runs", s.handleListRuns)
+	mux.HandleFunc("POST /runs", s.handleCreateRun)
+	mux.HandleFunc("GET /runs/{id}", s.handleGetRun)
+	mux.HandleFunc("GET /runs/{id}/logs/{control}", s.handleRunLog)
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleControls(w http.ResponseWriter, r *http.Request) {
+	type control struct {
+		ID          string `json:"id"`
+		Title       string `json:"title"`
+		Remediation bool   `json:"remediation"`
+	}
+	out := make([]control, 0, len(s.app.controls))
+	for _, c := range s.app.controls {
+		_, ok := c.(Remediator)
+		out = append(out, control{ID: c.ID(), Title: c.Title(), Remediation: ok})
+	}
+	slices.SortFunc(out, func(a, b control) int { return strings.Compare(a.ID, b.ID) })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	out := make([]RunRecord, 0, len(s.runs))
+	for _, rec := range s.runs {
+		out = append(out, *rec)
+	}
+	s.mu.Unlock()
+	slices.SortFunc(out, func(a, b RunRecord) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		EnvTag   string   `json:"env_tag"`
+		Controls []string `json:"controls"`
+	}
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	}
+
+	ids := req.Controls
+	if len(ids) == 0 {
+		ids = s.cfg.Controls
+	}
+	controls, err := s.app.selectControls(strings.Join(ids, ","), s.cfg.Local)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cfg := s.cfg
+	if v := strings.TrimSpace(req.EnvTag); v != "" {
+		cfg.EnvTag = v
+	}
+
+	rec := &RunRecord{
+		ID:        NewRunID(),
+		Status:    RunQueued,
+		EnvTag:    cfg.EnvTag,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, c := range controls {
+		rec.Controls = append(rec.Controls, c.ID())
+	}
+	cfg.ReportDir = filepath.Join(s.runsDir(), rec.ID)
+	cfg.LogDir = filepath.Join(cfg.ReportDir, "logs")
+
+	// The record is added under the lock before a worker can update it.
+	s.mu.Lock()
+	select {
+	case s.queue <- runJob{id: rec.ID, cfg: cfg, controls: controls}:
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "run queue is full")
+		return
+	}
+	s.runs[rec.ID] = rec
+	if err := s.persist(rec); err != nil {
+		s.log.Error("failed to persist run", "run_id", rec.ID, "error", err.Error())
+	}
+	resp := *rec
+	s.mu.Unlock()
+	s.log.Info("run queued", "run_id", rec.ID, "env_tag", rec.EnvTag, "controls", strings.Join(rec.Controls, ","))
+
+	w.Header().Set("Location", "/runs/"+rec.ID)
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	rec, ok := s.record(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+
+	resp := struct {
+		RunRecord
+		Report *Report `json:"report,omitempty"`
+	}{RunRecord: rec}
+	if rec.ReportPath != "" {
+		report, err := ReadReport(rec.ReportPath)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read report: "+err.Error())
+			return
+		}
+		resp.Report = &report
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRunLog(w http.ResponseWriter, r *http.Request) {
+	rec, ok := s.record(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	controlID := r.PathValue("control")
+	if !slices.Contains(rec.Controls, controlID) {
+		writeError(w, http.StatusNotFound, "control not part of this run")
+		return
+	}
+
+	// Control logs are named cisctl_<id>_<timestamp>.log; the glob also
+	// finds the log of a control that is still running.
+	matches, _ := filepath.Glob(filepath.Join(s.runsDir(), rec.ID, "logs", "cisctl_"+sanitize(controlID)+"_*.log"))
+	if len(matches) == 0 {
+		writeError(w, http.StatusNotFound, "no log yet for control "+controlID)
+		return
+	}
+	slices.Sort(matches)
+	f, err := os.Open(matches[len(matches)-1])
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.Copy(w, f)
+}
+
+func (s *Server) execute(ctx context.Context, job runJob) {
+	s.update(job.id, func(rec *RunRecord) {
+		now := time.Now().UTC()
+		rec.Status = RunRunning
+		rec.StartedAt = &now
+	})
+
+	var (
+		report     Report
+		reportPath string
+		logPath    string
+	)
+	sess, err := newSession(job.cfg, job.id)
+	if err == nil {
+		sess.out = io.Discard
+		logPath = sess.logPath
+		report, reportPath, err = s.app.executeRun(ctx, sess, job.controls)
+		sess.Close()
+	}
+
+	s.update(job.id, func(rec *RunRecord) {
+		now := time.Now().UTC()
+		rec.FinishedAt = &now
+		rec.LogPath = logPath
+		if err != nil {
+			rec.Status = RunError
+			rec.Error = err.Error()
+			return
+		}
+		rec.Status = RunCompleted
+		rec.Summary = &report.Summary
+		rec.ReportPath = reportPath
+	})
+	if err != nil {
+		s.log.Error("run failed", "run_id", job.id, "error", err.Error())
+	} else {
+		s.log.Info("run completed", "run_id", job.id, "pass", report.Summary.Pass, "fail", report.Summary.Fail)
+	}
+}
+
+func (s *Server) record(id string) (RunRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.runs[id]
+	if !ok {
+		return RunRecord{}, false
+	}
+	return *rec, true
+}
+
+func (s *Server) update(id string, fn func(*RunRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.runs[id]
+	fn(rec)
+	if err := s.persist(rec); err != nil {
+		s.log.Error("failed to persist run", "run_id", id, "error", err.Error())
+	}
+}
+
+func (s *Server) persist(rec *RunRecord) error {
+	dir := filepath.Join(s.runsDir(), rec.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, runRecordFile), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rec)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// ListenAndServe serves the API on addr until ctx is done, then waits for
+// in-flight requests and runs to stop.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	s.Wait()
+	return err
+}
@@ -0,0 +1,458 @@
+package cisctl
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commonFlags are the flags shared by every command that evaluates controls.
+type commonFlags struct {
+	root           *string
+	envTag         *string
+	controls       *string
+	dotEnv         *string
+	dotEnvOverride *bool
+	logLevel       *string
+	logFormat      *string
+	waivers        *string
+	config         *string
+	profile        *string
+	tokenFile      *string
+	tokenCommand   *string
+	doctlContext   *string
+	includeTags    *string
+	excludeTags    *string
+	nameRegex      *string
+	regions        *string
+	project        *string
+	hosts          *string
+	hostGroups     *string
+	local          *bool
+	controlsDir    *string
+	execControls   *string
+	execSkip       *bool
+}
+
+func addCommonFlags(fs *flag.FlagSet) *commonFlags {
+	f := addConfigFlags(fs)
+	f.controls = fs.String("controls", "", "Comma-separated list of control IDs (default: all)")
+	f.logLevel = fs.String("log-level", "", "Log level: debug, info, warn, error (defaults LOG_LEVEL or info)")
+	f.logFormat = fs.String("log-format", "", "Log format: text or json (defaults LOG_FORMAT or text)")
+	f.waivers = fs.String("waivers", "", "Waivers YAML file (defaults WAIVERS_FILE or <root>/waivers.yaml if exists)")
+	f.local = fs.Bool("local", false, "Run only the host controls, on this machine instead of over SSH; no DO token needed (defaults CISCTL_LOCAL)")
+	return f
+}
+
+// addConfigFlags registers only the flags needed to load the configuration,
+// for commands that do not run controls.
+func addConfigFlags(fs *flag.FlagSet) *commonFlags {
+	return &commonFlags{
+		root:           fs.String("root", "", "Deployment root directory (defaults to auto-detect)"),
+		envTag:         fs.String("env-tag", "", "DigitalOcean tag name to scope droplets (defaults ENV_TAG or env:demo)"),
+		dotEnv:         fs.String("dotenv", "", "Path to .env file (default: <root>/.env if exists)"),
+		dotEnvOverride: fs.Bool("dotenv-override", false, "Let .env values replace variables already set in the environment"),
+		config:         fs.String("config", "", "Config file with profiles (defaults CISCTL_CONFIG or <root>/cisctl.yaml if exists)"),
+		profile:        fs.String("profile", "", "Profile from the config file (defaults CISCTL_PROFILE or default_profile)"),
+		tokenFile:      fs.String("token-file", "", "Read the DO token from this file; it must not be group or world readable"),
+		tokenCommand:   fs.String("token-command", "", "Read the DO token from this command's output (e.g. 'pass show do/token')"),
+		doctlContext:   fs.String("context", "", "Read the DO token from this doctl auth context"),
+		includeTags:    fs.String("include-tags", "", "Comma-separated droplet tags scanned besides --env-tag (defaults SCOPE_INCLUDE_TAGS)"),
+		excludeTags:    fs.String("exclude-tags", "", "Comma-separated tags whose droplets and volumes are skipped (defaults SCOPE_EXCLUDE_TAGS)"),
+		nameRegex:      fs.String("name-regex", "", "Only scan droplets whose name matches this regular expression (defaults SCOPE_NAME_REGEX)"),
+		regions:        fs.String("regions", "", "Comma-separated region slugs to scan (defaults SCOPE_REGIONS)"),
+		project:        fs.String("project", "", "Only scan resources in this DO project, by name or ID (defaults SCOPE_PROJECT)"),
+		hosts:          fs.String("hosts", "", "Hosts for SSH-based controls: do, ansible:<hosts.ini> or static:<hosts.yaml> (defaults HOSTS_SOURCE or do)"),
+		hostGroups:     fs.String("host-groups", "", "Comma-separated inventory groups to check (defaults HOST_GROUPS or all)"),
+		controlsDir:    fs.String("controls-dir", "", "Directory of YAML host-check controls (defaults CISCTL_CONTROLS_DIR or <root>/controls.d if exists)"),
+		execControls:   fs.String("exec-controls", "", "Comma-separated control executables, directories or globs relative to the root, e.g. 'scripts/bash/controls/*.sh' (defaults CISCTL_EXEC_CONTROLS)"),
+		execSkip:       fs.Bool("exec-skip-defined", false, "Skip exec controls whose ID a built-in or YAML control already has, instead of failing (defaults CISCTL_EXEC_SKIP_DEFINED)"),
+		controls:       new(string),
+		logLevel:       new(string),
+		logFormat:      new(string),
+		waivers:        new(string),
+		local:          new(bool),
+	}
+}
+
+// loadEnv resolves the deployment root and loads its .env file, returning the
+// keys it set. A missing default .env is not an error; a missing --dotenv
+// file is.
+func (f *commonFlags) loadEnv() (string, map[string]bool, error) {
+	rootDir := strings.TrimSpace(*f.root)
+	if rootDir == "" {
+		rootDir = FindDeploymentRoot()
+	}
+	rootDir, _ = filepath.Abs(rootDir)
+
+	dotEnvPath := strings.TrimSpace(*f.dotEnv)
+	if dotEnvPath == "" {
+		dotEnvPath = filepath.Join(rootDir, ".env")
+		if !fileExists(dotEnvPath) {
+			return rootDir, nil, nil
+		}
+	}
+	vars, err := LoadDotEnv(dotEnvPath, *f.dotEnvOverride)
+	if err != nil {
+		return "", nil, err
+	}
+	dotenv := map[string]bool{}
+	for _, v := range vars {
+		dotenv[v.Key] = true
+	}
+	return rootDir, dotenv, nil
+}
+
+// loadProfile reads the config file, if any, and returns the selected
+// profile. A missing default config file is not an error.
+func (f *commonFlags) loadProfile(rootDir string) (*ConfigFile, *Profile, error) {
+	path := firstNonEmpty(strings.TrimSpace(*f.config), os.Getenv("CISCTL_CONFIG"))
+	name := firstNonEmpty(strings.TrimSpace(*f.profile), os.Getenv("CISCTL_PROFILE"))
+	if path == "" {
+		path = filepath.Join(rootDir, "cisctl.yaml")
+		if !fileExists(path) {
+			if name != "" {
+				return nil, nil, fmt.Errorf("profile %q selected but %s does not exist", name, path)
+			}
+			return nil, nil, nil
+		}
+	}
+	file, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile, err := file.Profile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, profile, nil
+}
+
+// loadConfig loads the environment and applies flag overrides on top of
+// LoadConfig, for commands that call the DigitalOcean API. These have no
+// --local flag and ignore CISCTL_LOCAL, which agent hosts set for their runs.
+func (f *commonFlags) loadConfig(ctx context.Context) (Config, error) {
+	cfg, err := f.loadSettings()
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Local = false
+	if err := cfg.requireToken(ctx); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadRunConfig is loadConfig for the commands that evaluate controls. Local
+// runs only check this machine and need no token.
+func (f *commonFlags) loadRunConfig(ctx context.Context) (Config, error) {
+	cfg, err := f.loadSettings()
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.Local {
+		return cfg, nil
+	}
+	if err := cfg.requireToken(ctx); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// requireToken reads the DO access token from its source and fails when there
+// is none.
+func (cfg *Config) requireToken(ctx context.Context) error {
+	if err := cfg.resolveToken(ctx); err != nil {
+		return err
+	}
+	if cfg.DOAccessToken == "" {
+		return errMissingToken
+	}
+	return nil
+}
+
+// loadSettings is loadConfig without the token requirement.
+func (f *commonFlags) loadSettings() (Config, error) {
+	rootDir, dotenv, err := f.loadEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	file, profile, err := f.loadProfile(rootDir)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg, err := loadSettings(rootDir, profile, *f.envTag, dotenv)
+	if err != nil {
+		return Config{}, err
+	}
+	if file != nil {
+		cfg.ConfigFile = file.Path
+		if profile != nil {
+			cfg.Profile = firstNonEmpty(strings.TrimSpace(*f.profile), os.Getenv("CISCTL_PROFILE"), file.DefaultProfile)
+		}
+	}
+	if v := strings.TrimSpace(*f.controls); v != "" {
+		cfg.Controls = splitList(v)
+	}
+	if v := strings.TrimSpace(*f.controlsDir); v != "" {
+		cfg.ControlsDir = v
+	}
+	if v := strings.TrimSpace(*f.execControls); v != "" {
+		cfg.ExecControls = splitList(v)
+	}
+	if *f.execSkip {
+		cfg.ExecSkipDefined = true
+	}
+	if v := strings.TrimSpace(*f.includeTags); v != "" {
+		cfg.Scope.IncludeTags = splitList(v)
+	}
+	if v := strings.TrimSpace(*f.excludeTags); v != "" {
+		cfg.Scope.ExcludeTags = splitList(v)
+	}
+	if v := strings.TrimSpace(*f.nameRegex); v != "" {
+		cfg.Scope.NameRegex = v
+	}
+	if v := strings.TrimSpace(*f.regions); v != "" {
+		cfg.Scope.Regions = splitList(v)
+	}
+	if v := strings.TrimSpace(*f.project); v != "" {
+		cfg.Scope.Project = v
+	}
+	if err := cfg.Scope.compile(); err != nil {
+		return Config{}, err
+	}
+	if v := strings.TrimSpace(*f.hosts); v != "" {
+		if _, _, err := parseHostsSource(v); err != nil {
+			return Config{}, err
+		}
+		cfg.HostsSource = v
+	}
+	if v := strings.TrimSpace(*f.hostGroups); v != "" {
+		cfg.HostGroups = splitList(v)
+	}
+	if *f.local {
+		cfg.Local = true
+	}
+	if src, err := f.tokenSource(); err != nil {
+		return Config{}, err
+	} else if src.Kind != "" {
+		cfg.DOAccessToken = ""
+		cfg.TokenSource = src
+	}
+	if v := strings.TrimSpace(*f.logLevel); v != "" {
+		level, err := ParseLogLevel(v)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.LogLevel = level
+	}
+	if v := strings.TrimSpace(*f.logFormat); v != "" {
+		cfg.LogFormat = v
+	}
+	if v := strings.TrimSpace(*f.waivers); v != "" {
+		cfg.WaiversFile = v
+	}
+	if cfg.LogFormat != LogFormatText && cfg.LogFormat != LogFormatJSON {
+		return Config{}, fmt.Errorf("invalid log format %q (want text or json)", cfg.LogFormat)
+	}
+	return cfg, nil
+}
+
+// tokenSource returns the token source chosen by flags, if any.
+func (f *commonFlags) tokenSource() (TokenSource, error) {
+	var sources []TokenSource
+	if v := strings.TrimSpace(*f.tokenFile); v != "" {
+		sources = append(sources, TokenSource{Kind: TokenSourceFile, Ref: v})
+	}
+	if v := strings.TrimSpace(*f.tokenCommand); v != "" {
+		sources = append(sources, TokenSource{Kind: TokenSourceCommand, Ref: v})
+	}
+	if v := strings.TrimSpace(*f.doctlContext); v != "" {
+		sources = append(sources, TokenSource{Kind: TokenSourceDoctl, Ref: v})
+	}
+	switch len(sources) {
+	case 0:
+		return TokenSource{}, nil
+	case 1:
+		return sources[0], nil
+	default:
+		return TokenSource{}, fmt.Errorf("use only one of --token-file, --token-command and --context")
+	}
+}
+
+// selectControls returns the controls named in a comma-separated ID list, or
+// all controls when the list is empty. Local runs only have the host
+// controls.
+func (a *App) selectControls(ids string, local bool) ([]Control, error) {
+	if local {
+		return a.selectHostControls(ids)
+	}
+	if strings.TrimSpace(ids) == "" {
+		return a.controls, nil
+	}
+	want := map[string]bool{}
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		want[id] = true
+	}
+	filtered := make([]Control, 0, len(a.controls))
+	for _, c := range a.controls {
+		if want[c.ID()] {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no controls matched: %s", ids)
+	}
+	return filtered, nil
+}
+
+func (a *App) selectHostControls(ids string) ([]Control, error) {
+	controls, err := a.selectControls(ids, false)
+	if err != nil {
+		return nil, err
+	}
+	var hostControls []Control
+	for _, c := range controls {
+		if _, ok := c.(HostControl); ok {
+			hostControls = append(hostControls, c)
+		} else if strings.TrimSpace(ids) != "" {
+			return nil, fmt.Errorf("control %s needs the DigitalOcean API and cannot run with --local", c.ID())
+		}
+	}
+	if len(hostControls) == 0 {
+		return nil, fmt.Errorf("no host controls to run with --local")
+	}
+	return hostControls, nil
+}
+
+// session holds the clients and run log shared by the controls of one run.
+type session struct {
+	cfg       Config
+	do        *DOClient
+	exec      Executor
+	hosts     HostSource
+	log       *Logger
+	logPath   string
+	runID     string
+	startedAt time.Time
+	// out receives the per-control progress lines.
+	out     io.Writer
+	waivers *WaiverSet
+	// dropletTags caches droplet tags by ID for tag-based waivers.
+	dropletTags map[string][]string
+}
+
+// newSession prepares the clients and run log for one run. An empty runID
+// gets a random one.
+func newSession(cfg Config, runID string) (*session, error) {
+	if err := os.MkdirAll(cfg.LogDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log dir: %w", err)
+	}
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create report dir: %w", err)
+	}
+
+	// Local runs neither call the API nor use SSH.
+	var doClient *DOClient
+	var executor Executor = LocalExecutor{Timeout: cfg.LocalTimeout}
+	if !cfg.Local {
+		c, err := NewDOClient(cfg.DOAccessToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init DO client: %w", err)
+		}
+		doClient = c
+
+		sshRunner, err := NewSSHRunner(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init SSH runner: %w", err)
+		}
+		executor = sshRunner
+	}
+
+	hosts, err := NewHostSource(cfg, doClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init host source: %w", err)
+	}
+
+	var waivers *WaiverSet
+	if cfg.WaiversFile != "" {
+		if waivers, err = LoadWaivers(cfg.WaiversFile); err != nil {
+			return nil, fmt.Errorf("failed to load waivers: %w", err)
+		}
+	}
+
+	if runID == "" {
+		runID = NewRunID()
+	}
+	s := &session{
+		cfg:       cfg,
+		do:        doClient,
+		exec:      executor,
+		hosts:     hosts,
+		runID:     runID,
+		startedAt: time.Now().UTC(),
+		out:       os.Stdout,
+		waivers:   waivers,
+	}
+	s.log, s.logPath, err = NewRunLogger(LogOptions{
+		Dir:    cfg.LogDir,
+		Format: cfg.LogFormat,
+		Level:  cfg.LogLevel,
+		RunID:  s.runID,
+	}, s.startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init run log: %w", err)
+	}
+	return s, nil
+}
+
+func (s *session) Close() error {
+	return s.log.Close()
+}
+
+func (s *session) deps(log *Logger) Deps {
+	return Deps{
+		Config:     s.cfg,
+		DO:         s.do,
+		Exec:       s.exec,
+		HostSource: s.hosts,
+		Log:        log,
+	}
+}
+
+// findingTags returns the tags of the droplet behind f, listing the env's
+// droplets once per session.
+func (s *session) findingTags(ctx context.Context, f Finding) []string {
+	if f.ResourceType != "droplet" {
+		return nil
+	}
+	if s.dropletTags == nil {
+		s.dropletTags = map[string][]string{}
+		droplets, err := s.do.ListScopedDroplets(ctx, s.cfg.EnvTag, s.cfg.Scope)
+		if err != nil {
+			s.log.Warn("failed to list droplet tags for waivers", "error", err.Error())
+		}
+		for _, d := range droplets {
+			s.dropletTags[strconv.Itoa(d.ID)] = d.Tags
+		}
+	}
+	return s.dropletTags[f.ResourceID]
+}
+
+// newReport returns an empty report stamped with the session's run metadata.
+func (s *session) newReport() Report {
+	r := NewReport(s.cfg, s.startedAt)
+	r.RunID = s.runID
+	return r
+}
@@ -0,0 +1,261 @@
+package cisctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"cisctl/internal/assets"
+)
+
+const slackHeader = "DO CIS Demo - CIS Report"
+
+// SlackOptions mirrors the environment read by scripts/bash/slack_notify.sh.
+type SlackOptions struct {
+	RunURL    string
+	EnvName   string
+	JobStatus string
+	// Remediation maps control IDs to recommendations (scripts/slack/remediation.json).
+	Remediation        map[string]string
+	MaxPassLines       int
+	MaxFailLines       int
+	MaxRecommendations int
+}
+
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji *bool  `json:"emoji,omitempty"`
+}
+
+type slackButton struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []any       `json:"elements,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// SlackMessage is the webhook payload. A report message is wrapped in a
+// colored attachment; the no-report message uses top-level blocks.
+type SlackMessage struct {
+	Blocks      []slackBlock      `json:"blocks,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+func plainText(s string) *slackText {
+	no := false
+	return &slackText{Type: "plain_text", Text: s, Emoji: &no}
+}
+
+func mrkdwn(s string) slackText {
+	return slackText{Type: "mrkdwn", Text: s}
+}
+
+// LoadRemediationMap reads a control ID -> recommendation map from path, or
+// the embedded scripts/slack/remediation.json when path is empty.
+func LoadRemediationMap(path string) (map[string]string, error) {
+	data := assets.RemediationJSON
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid remediation map: %w", err)
+	}
+	return m, nil
+}
+
+// BuildSlackMessage renders report as Block Kit. A nil report produces the
+// notice sent when the pipeline failed before any control ran.
+func BuildSlackMessage(report *Report, opts SlackOptions) SlackMessage {
+	header := slackBlock{Type: "header", Text: plainText(slackHeader)}
+	var buttons []slackBlock
+	if opts.RunURL != "" {
+		buttons = append(buttons, slackBlock{Type: "actions", Elements: []any{
+			slackButton{Type: "button", Text: *plainText("Open GitHub Run"), URL: opts.RunURL},
+		}})
+	}
+
+	if report == nil {
+		blocks := []slackBlock{
+			header,
+			{Type: "section", Fields: []slackText{
+				mrkdwn("*Environment:*\n" + opts.EnvName),
+				mrkdwn("*Status:*\n" + opts.JobStatus),
+			}},
+		}
+		blocks = append(blocks, buttons...)
+		blocks = append(blocks,
+			slackBlock{Type: "divider"},
+			slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "No `cisctl_report_*.json` was generated.\n" +
+				"This usually means the pipeline failed before running CIS controls.\n" +
+				"Please open GitHub Run artifacts (logs/reports)."}},
+		)
+		return SlackMessage{Blocks: blocks}
+	}
+
+	var passLines, failLines []string
+	var failing []string
+	for _, r := range report.Results {
+		if r.Status == StatusWaived {
+			failLines = append(failLines, fmt.Sprintf("%s  %s  %s", r.ControlID, StatusWaived, r.Title))
+			continue
+		}
+		if r.Status == StatusNotApplicable {
+			passLines = append(passLines, fmt.Sprintf("%s  %s  %s", r.ControlID, StatusNotApplicable, r.Title))
+			continue
+		}
+		if r.Pass {
+			passLines = append(passLines, fmt.Sprintf("%s  %s", r.ControlID, r.Title))
+			continue
+		}
+		status := "FAIL"
+		if r.Error != "" {
+			status = "ERROR"
+		}
+		failLines = append(failLines, fmt.Sprintf("%s  %s  %s", r.ControlID, status, r.Title))
+		if !slices.Contains(failing, r.ControlID) {
+			failing = append(failing, r.ControlID)
+		}
+	}
+	slices.Sort(failing)
+	if opts.MaxRecommendations >= 0 && len(failing) > opts.MaxRecommendations {
+		failing = failing[:opts.MaxRecommendations]
+	}
+
+	var rec strings.Builder
+	for _, id := range failing {
+		if v := opts.Remediation[id]; v != "" {
+			fmt.Fprintf(&rec, "• *%s*: %s\n", id, v)
+		}
+	}
+
+	totals := fmt.Sprintf("PASS=%d  FAIL=%d  WARN=%d", report.Summary.Pass, report.Summary.Fail, report.Summary.Waived)
+	blocks := []slackBlock{
+		header,
+		{Type: "section", Fields: []slackText{
+			mrkdwn("*Environment:*\n" + opts.EnvName),
+			mrkdwn("*Status:*\n" + opts.JobStatus),
+			mrkdwn("*Totals:*\n" + totals),
+		}},
+		{Type: "context", Elements: []any{
+			mrkdwn("Tip: Lists may be truncated if too long. See full details in GitHub artifacts (logs/reports)."),
+		}},
+	}
+	blocks = append(blocks, buttons...)
+	blocks = append(blocks,
+		slackBlock{Type: "divider"},
+		slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*FAIL/WARN*\n```" + truncateLines(failLines, opts.MaxFailLines) + "```"}},
+		slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*PASS*\n```" + truncateLines(passLines, opts.MaxPassLines) + "```"}},
+	)
+	if rec.Len() > 0 {
+		blocks = append(blocks,
+			slackBlock{Type: "divider"},
+			slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*Recommendations*\n" + rec.String()}},
+		)
+	}
+
+	return SlackMessage{Attachments: []slackAttachment{{
+		Color:  slackColor(opts.JobStatus, report.Summary.Fail, report.Summary.Waived),
+		Blocks: blocks,
+	}}}
+}
+
+// BuildSlackDiffMessage lists the findings that changed state between two
+// runs, for `cisctl watch`.
+func BuildSlackDiffMessage(diff ReportDiff, opts SlackOptions) SlackMessage {
+	var lines []string
+	for _, it := range diff.Items {
+		if it.Status == DiffPersisting {
+			continue
+		}
+		f := it.New
+		if f == nil {
+			f = it.Old
+		}
+		resource := it.ResourceType
+		if it.ResourceName != "" {
+			resource += "/" + it.ResourceName
+		}
+		line := fmt.Sprintf("%s  %s  %s", strings.ToUpper(it.Status), it.ControlID, resource)
+		if f.Failing() && f.Reason != "" {
+			line += "  " + f.Reason
+		}
+		lines = append(lines, line)
+	}
+
+	totals := fmt.Sprintf("NEW=%d  RESOLVED=%d  CHANGED=%d", diff.Summary.New, diff.Summary.Resolved, diff.Summary.ChangedEvidence)
+	blocks := []slackBlock{
+		{Type: "header", Text: plainText(slackHeader + " changes")},
+		{Type: "section", Fields: []slackText{
+			mrkdwn("*Environment:*\n" + opts.EnvName),
+			mrkdwn("*Changes:*\n" + totals),
+		}},
+		{Type: "divider"},
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "```" + truncateLines(lines, opts.MaxFailLines) + "```"}},
+	}
+
+	color := "warning"
+	switch {
+	case diff.Summary.New > 0:
+		color = "danger"
+	case diff.Summary.Resolved > 0 && diff.Summary.ChangedEvidence == 0:
+		color = "good"
+	}
+	return SlackMessage{Attachments: []slackAttachment{{Color: color, Blocks: blocks}}}
+}
+
+// slackColor follows slack_notify.sh; waived controls count as warnings.
+func slackColor(jobStatus string, fail int, warn int) string {
+	if jobStatus != "success" || fail != 0 {
+		return "danger"
+	}
+	if warn != 0 {
+		return "warning"
+	}
+	return "good"
+}
+
+// truncateLines keeps the first max lines and notes how many were dropped.
+func truncateLines(lines []string, max int) string {
+	if len(lines) == 0 {
+		return "None\n"
+	}
+	if max < 0 || len(lines) <= max {
+		return strings.Join(lines, "\n") + "\n"
+	}
+	out := strings.Join(lines[:max], "\n")
+	if max > 0 {
+		out += "\n"
+	}
+	return out + fmt.Sprintf("... and %d more (see artifacts)\n", len(lines)-max)
+}
+
+// PostSlack posts msg to an incoming webhook, retrying rate-limited (429) and
+// 5xx responses up to retries times.
+func PostSlack(ctx context.Context, client *http.Client, webhookURL string, msg SlackMessage, retries int) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	return postWithRetry(ctx, client, webhookURL, header, body, retries)
+}
@@ -0,0 +1,190 @@
+package cisctl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slackStandIn is a local Slack webhook that answers with the given status
+// codes in turn, then 200, and records the payloads it receives.
+type slackStandIn struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   []SlackMessage
+	types    []string
+}
+
+func newSlackStandIn(t *testing.T, statuses ...int) *slackStandIn {
+	s := &slackStandIn{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var msg SlackMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bodies = append(s.bodies, msg)
+		s.types = append(s.types, r.Header.Get("Content-Type"))
+		if len(s.statuses) > 0 {
+			status := s.statuses[0]
+			s.statuses = s.statuses[1:]
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testSlackReport() Report {
+	r := NewReport(Config{EnvTag: "env:demo"}, time.Now())
+	r.Results = []ControlResult{
+		{ControlID: "2.1.1", Title: "Backups", Status: StatusPass, Pass: true},
+		{ControlID: "2.1.2", Title: "Firewall", Status: StatusFail, Findings: []Finding{{ResourceType: "droplet", ResourceID: "1", Reason: "no firewall"}}},
+	}
+	r.Summary = Summarize(r.Results)
+	return r
+}
+
+func fieldTexts(b slackBlock) []string {
+	var texts []string
+	for _, f := range b.Fields {
+		texts = append(texts, f.Text)
+	}
+	return texts
+}
+
+func TestPostSlackBlockKit(t *testing.T) {
+	srv := newSlackStandIn(t)
+	report := testSlackReport()
+	msg := BuildSlackMessage(&report, SlackOptions{
+		RunURL:             "https://example.com/run/1",
+		EnvName:            "env:demo",
+		JobStatus:          "success",
+		Remediation:        map[string]string{"2.1.2": "Attach a firewall"},
+		MaxPassLines:       25,
+		MaxFailLines:       50,
+		MaxRecommendations: 15,
+	})
+	if err := PostSlack(context.Background(), srv.Client(), srv.URL, msg, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srv.bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(srv.bodies))
+	}
+	if srv.types[0] != "application/json" {
+		t.Errorf("Content-Type = %q", srv.types[0])
+	}
+	got := srv.bodies[0]
+	if len(got.Attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(got.Attachments))
+	}
+	att := got.Attachments[0]
+	if att.Color != "danger" {
+		t.Errorf("color = %q, want danger for a failing control", att.Color)
+	}
+	var types []string
+	for _, b := range att.Blocks {
+		types = append(types, b.Type)
+	}
+	wantTypes := []string{"header", "section", "context", "actions", "divider", "section", "section", "divider", "section"}
+	if !slices.Equal(types, wantTypes) {
+		t.Errorf("block types = %v, want %v", types, wantTypes)
+	}
+	if att.Blocks[0].Text == nil || att.Blocks[0].Text.Text != slackHeader {
+		t.Errorf("header = %+v", att.Blocks[0].Text)
+	}
+	fields := fieldTexts(att.Blocks[1])
+	for _, want := range []string{"*Environment:*\nenv:demo", "*Status:*\nsuccess", "*Totals:*\nPASS=1  FAIL=1  WARN=0"} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("fields %q lack %q", fields, want)
+		}
+	}
+	if text := att.Blocks[5].Text.Text; !strings.Contains(text, "2.1.2  FAIL  Firewall") {
+		t.Errorf("FAIL/WARN section = %q", text)
+	}
+	if text := att.Blocks[6].Text.Text; !strings.Contains(text, "2.1.1  Backups") {
+		t.Errorf("PASS section = %q", text)
+	}
+	if text := att.Blocks[8].Text.Text; !strings.Contains(text, "*2.1.2*: Attach a firewall") {
+		t.Errorf("recommendations = %q", text)
+	}
+}
+
+func TestPostSlackRetryAfter(t *testing.T) {
+	srv := newSlackStandIn(t, http.StatusTooManyRequests)
+	msg := BuildSlackMessage(nil, SlackOptions{EnvName: "env:demo", JobStatus: "failure"})
+
+	start := time.Now()
+	if err := PostSlack(context.Background(), srv.Client(), srv.URL, msg, 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.bodies) != 2 {
+		t.Errorf("got %d requests, want 2", len(srv.bodies))
+	}
+	// Retry-After: 0 replaces the one second backoff.
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("retry took %s; Retry-After was not honored", elapsed)
+	}
+}
+
+func TestPostSlackGivesUp(t *testing.T) {
+	srv := newSlackStandIn(t, http.StatusBadRequest)
+	msg := BuildSlackMessage(nil, SlackOptions{})
+	if err := PostSlack(context.Background(), srv.Client(), srv.URL, msg, 3); err == nil {
+		t.Fatal("PostSlack succeeded on 400")
+	}
+	if len(srv.bodies) != 1 {
+		t.Errorf("got %d requests, want 1; client errors are not retried", len(srv.bodies))
+	}
+}
+
+// TestNotifySlackDefaultJobStatus checks that, like slack_notify.sh, an unset
+// JOB_STATUS is reported as unknown and colored as danger even when every
+// control passed.
+func TestNotifySlackDefaultJobStatus(t *testing.T) {
+	srv := newSlackStandIn(t)
+	dir := t.TempDir()
+	for _, key := range []string{"JOB_STATUS", "ENV_NAME", "SLACK_WEBHOOK_URL", "CISCTL_CONFIG", "CISCTL_PROFILE", "REPORT_DIR"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("HOME", dir)
+
+	report := NewReport(Config{EnvTag: "env:demo"}, time.Now())
+	report.Results = []ControlResult{{ControlID: "2.1.1", Title: "Backups", Status: StatusPass, Pass: true}}
+	report.Summary = Summarize(report.Results)
+	path := filepath.Join(dir, "cisctl_report_20250101000000.json")
+	if err := WriteJSONFile(path, report); err != nil {
+		t.Fatal(err)
+	}
+
+	runCapture(t, "notify", "slack", "--root", dir, "--report", path, "--webhook-url", srv.URL, "--retries", "0")
+	if len(srv.bodies) != 1 || len(srv.bodies[0].Attachments) != 1 {
+		t.Fatalf("unexpected payloads: %+v", srv.bodies)
+	}
+	att := srv.bodies[0].Attachments[0]
+	if att.Color != "danger" {
+		t.Errorf("color = %q, want danger", att.Color)
+	}
+	if fields := fieldTexts(att.Blocks[1]); !slices.Contains(fields, "*Status:*\nunknown") {
+		t.Errorf("fields %q lack the unknown status", fields)
+	}
+}
@@ -3,9 +3,12 @@ package cisctl
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -20,6 +23,12 @@ type SSHRunner struct {
 	keyPath string
 	signer  ssh.Signer
 	initErr error
+
+	// hosts holds per-host user, port and key overrides by Host.ID, and
+	// signers caches the keys they name.
+	mu      sync.Mutex
+	hosts   map[string]Host
+	signers map[string]ssh.Signer
 }
 
 func NewSSHRunner(cfg Config) (*SSHRunner, error) {
@@ -57,33 +66,116 @@ func NewSSHRunner(cfg Config) (*SSHRunner, error) {
 	return r, nil
 }
 
-func (r *SSHRunner) RunCommand(ip string, cmd string) (string, error) {
+// AddHosts registers the user, port and key of inventory hosts so commands
+// sent to them use these instead of the config's SSH settings.
+func (r *SSHRunner) AddHosts(hosts []Host) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hosts == nil {
+		r.hosts = map[string]Host{}
+	}
+	for _, h := range hosts {
+		if h.Address != "" {
+			r.hosts[h.ID()] = h
+		}
+	}
+}
+
+// RunCommand runs cmd on h with the settings registered for it by AddHosts,
+// or else those h carries.
+func (r *SSHRunner) RunCommand(h Host, cmd string) (string, error) {
 	if r == nil {
 		return "", errors.New("ssh runner is nil")
 	}
-	if r.initErr != nil {
-		return "", r.initErr
+	r.mu.Lock()
+	if known, ok := r.hosts[h.ID()]; ok {
+		h = known
+	}
+	r.mu.Unlock()
+	ip := h.Address
+
+	signer, err := r.hostSigner(h.KeyPath)
+	if err != nil {
+		return "", err
+	}
+	port := r.port
+	if h.Port > 0 {
+		port = h.Port
+	}
+	// An inventory user is used as is, like Ansible does.
+	user, fallback := r.user, r.userFallback
+	if h.User != "" {
+		user, fallback = h.User, ""
 	}
 
-	out, err := r.runWithUser(ip, r.user, cmd)
+	out, err := r.runWithUser(ip, port, signer, user, cmd)
 	if err == nil {
 		return out, nil
 	}
-	if r.userFallback != "" && r.userFallback != r.user {
-		out2, err2 := r.runWithUser(ip, r.userFallback, cmd)
+	// The command itself ran and failed; retrying as another user would run it twice.
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return out, err
+	}
+	if fallback != "" && fallback != user {
+		out2, err2 := r.runWithUser(ip, port, signer, fallback, cmd)
 		if err2 == nil {
 			return out2, nil
 		}
-		return out2, fmt.Errorf("ssh failed with %s and %s: %w", r.user, r.userFallback, err2)
+		return out2, fmt.Errorf("ssh failed with %s and %s: %w", user, fallback, err2)
 	}
 	return out, err
 }
 
-func (r *SSHRunner) runWithUser(ip string, user string, cmd string) (string, error) {
-	addr := fmt.Sprintf("%s:%d", ip, r.port)
+// KeyPath returns the expanded private key path.
+func (r *SSHRunner) KeyPath() string {
+	return r.keyPath
+}
+
+// Fingerprint returns the MD5 fingerprint of the public key, in the format
+// DigitalOcean shows for account SSH keys.
+func (r *SSHRunner) Fingerprint() (string, error) {
+	if r.initErr != nil {
+		return "", r.initErr
+	}
+	return ssh.FingerprintLegacyMD5(r.signer.PublicKey()), nil
+}
+
+// hostSigner returns the signer for a host's key path, or the default key
+// when the host has none.
+func (r *SSHRunner) hostSigner(keyPath string) (ssh.Signer, error) {
+	if keyPath == "" {
+		return r.signer, r.initErr
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.signers[keyPath]; ok {
+		return s, nil
+	}
+	expanded, err := expandPath(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", expanded, err)
+	}
+	if r.signers == nil {
+		r.signers = map[string]ssh.Signer{}
+	}
+	r.signers[keyPath] = signer
+	return signer, nil
+}
+
+func (r *SSHRunner) runWithUser(ip string, port int, signer ssh.Signer, user string, cmd string) (string, error) {
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
 	cfg := &ssh.ClientConfig{
 		User:            user,
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(r.signer)},
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 		Timeout:         r.timeout,
 	}
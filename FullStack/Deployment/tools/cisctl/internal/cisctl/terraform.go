@@ -0,0 +1,467 @@
+package cisctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PlanResourceTypes are the resource types `cisctl scan-plan` reads from a
+// plan; the plan controls only evaluate these.
+var PlanResourceTypes = []string{
+	"digitalocean_droplet",
+	"digitalocean_firewall",
+	"digitalocean_volume",
+	"digitalocean_spaces_bucket",
+	"digitalocean_cdn",
+	"digitalocean_monitor_alert",
+}
+
+// TerraformResource is one resource instance with the values Terraform plans
+// for it, or records in its state.
+type TerraformResource struct {
+	// Address is the instance address, e.g. module.spaces.digitalocean_cdn.this[0].
+	Address string
+	Type    string
+	Name    string
+	// Action is the planned change: create, update, replace or no-op. It is
+	// empty for state resources.
+	Action string
+	Values map[string]any
+	// unknown mirrors Values with true for attributes known only after apply.
+	unknown map[string]any
+}
+
+// ConfigAddress is Address without instance keys, as used in the
+// configuration, e.g. module.spaces.digitalocean_cdn.this.
+func (r TerraformResource) ConfigAddress() string {
+	return instanceKeyRe.ReplaceAllString(r.Address, "")
+}
+
+// String returns a string attribute, or "" when it is unset or unknown.
+func (r TerraformResource) String(key string) string {
+	s, _ := r.Values[key].(string)
+	return s
+}
+
+// Bool returns a bool attribute and whether it is known.
+func (r TerraformResource) Bool(key string) (value bool, known bool) {
+	b, ok := r.Values[key].(bool)
+	return b, ok
+}
+
+// Number returns a number attribute and whether it is known.
+func (r TerraformResource) Number(key string) (float64, bool) {
+	n, ok := r.Values[key].(float64)
+	return n, ok
+}
+
+// Strings returns the known elements of a list or set of strings or numbers,
+// such as a firewall's droplet_ids.
+func (r TerraformResource) Strings(key string) []string {
+	return anyStrings(r.Values[key])
+}
+
+// Blocks returns the nested blocks of key, such as lifecycle_rule.
+func (r TerraformResource) Blocks(key string) []map[string]any {
+	return anyBlocks(r.Values[key])
+}
+
+// Unknown reports whether any part of key is known only after apply.
+func (r TerraformResource) Unknown(key string) bool {
+	return anyTrue(r.unknown[key])
+}
+
+func anyStrings(v any) []string {
+	items, _ := v.([]any)
+	var out []string
+	for _, it := range items {
+		switch it := it.(type) {
+		case string:
+			out = append(out, it)
+		case float64:
+			out = append(out, strconv.FormatFloat(it, 'f', -1, 64))
+		}
+	}
+	return out
+}
+
+func anyBlocks(v any) []map[string]any {
+	items, _ := v.([]any)
+	var out []map[string]any
+	for _, it := range items {
+		if m, ok := it.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func anyTrue(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case []any:
+		return slices.ContainsFunc(v, anyTrue)
+	case map[string]any:
+		for _, it := range v {
+			if anyTrue(it) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var instanceKeyRe = regexp.MustCompile(`\[[^\]]*\]`)
+
+// TerraformPlan is the planned state of a `terraform show -json` plan.
+type TerraformPlan struct {
+	Path             string
+	TerraformVersion string
+	// Resources are the planned instances of PlanResourceTypes; instances the
+	// plan destroys are left out.
+	Resources []TerraformResource
+	config    tfConfig
+}
+
+type tfPlanJSON struct {
+	FormatVersion    string          `json:"format_version"`
+	TerraformVersion string          `json:"terraform_version"`
+	PlannedValues    json.RawMessage `json:"planned_values"`
+	ResourceChanges  []struct {
+		Address string `json:"address"`
+		Mode    string `json:"mode"`
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Change  struct {
+			Actions      []string       `json:"actions"`
+			After        map[string]any `json:"after"`
+			AfterUnknown map[string]any `json:"after_unknown"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+	Configuration tfConfig `json:"configuration"`
+}
+
+type tfConfig struct {
+	ProviderConfig map[string]struct {
+		Name        string                  `json:"name"`
+		Expressions map[string]tfExpression `json:"expressions"`
+	} `json:"provider_config"`
+	RootModule tfConfigModule `json:"root_module"`
+}
+
+type tfConfigModule struct {
+	Resources []struct {
+		Type        string                  `json:"type"`
+		Name        string                  `json:"name"`
+		Mode        string                  `json:"mode"`
+		Expressions map[string]tfExpression `json:"expressions"`
+	} `json:"resources"`
+	ModuleCalls map[string]struct {
+		Expressions map[string]tfExpression `json:"expressions"`
+		Module      tfConfigModule          `json:"module"`
+	} `json:"module_calls"`
+	Outputs map[string]struct {
+		Expression tfExpression `json:"expression"`
+	} `json:"outputs"`
+}
+
+// tfExpression is an attribute expression of the configuration: a literal
+// or the objects it references. Nested blocks decode to neither.
+type tfExpression struct {
+	ConstantValue json.RawMessage `json:"constant_value"`
+	References    []string        `json:"references"`
+}
+
+func (e *tfExpression) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil
+	}
+	type plain tfExpression
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// LoadTerraformPlan reads the output of `terraform show -json <planfile>`.
+func LoadTerraformPlan(path string) (*TerraformPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("PK")) {
+		return nil, fmt.Errorf("%s: binary plan file; convert it with `terraform show -json %s > plan.json`", path, path)
+	}
+	var raw tfPlanJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if raw.FormatVersion == "" || raw.PlannedValues == nil {
+		return nil, fmt.Errorf("%s: not a `terraform show -json` plan", path)
+	}
+
+	p := &TerraformPlan{Path: path, TerraformVersion: raw.TerraformVersion, config: raw.Configuration}
+	for _, rc := range raw.ResourceChanges {
+		if rc.Mode != "managed" || !slices.Contains(PlanResourceTypes, rc.Type) {
+			continue
+		}
+		action := planAction(rc.Change.Actions)
+		if action == "delete" {
+			continue
+		}
+		p.Resources = append(p.Resources, TerraformResource{
+			Address: rc.Address,
+			Type:    rc.Type,
+			Name:    rc.Name,
+			Action:  action,
+			Values:  rc.Change.After,
+			unknown: rc.Change.AfterUnknown,
+		})
+	}
+	return p, nil
+}
+
+// planAction names a change's action list; replacements list both delete and
+// create.
+func planAction(actions []string) string {
+	switch {
+	case len(actions) == 0:
+		return "no-op"
+	case len(actions) > 1:
+		return "replace"
+	default:
+		return actions[0]
+	}
+}
+
+// ResourcesOfType returns the planned instances of a resource type.
+func (p *TerraformPlan) ResourcesOfType(typ string) []TerraformResource {
+	var out []TerraformResource
+	for _, r := range p.Resources {
+		if r.Type == typ {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// References returns the configuration addresses of the resources that
+// attribute key of r is computed from, following module variables and
+// outputs. Plans leave such values unknown until apply, e.g. a firewall's
+// droplet_ids = [module.droplet.id] references
+// module.droplet.digitalocean_droplet.this.
+func (p *TerraformPlan) References(r TerraformResource, key string) []string {
+	path := modulePath(r.ConfigAddress())
+	mod, ok := p.config.module(path)
+	if !ok {
+		return nil
+	}
+	for _, res := range mod.Resources {
+		if res.Mode == "managed" && res.Type == r.Type && res.Name == r.Name {
+			seen := map[string]bool{}
+			p.config.resolve(path, res.Expressions[key].References, seen)
+			out := make([]string, 0, len(seen))
+			for addr := range seen {
+				out = append(out, addr)
+			}
+			slices.Sort(out)
+			return out
+		}
+	}
+	return nil
+}
+
+// ProviderLiteral reports whether a provider configuration sets key to a
+// literal value in the code rather than through a variable.
+func (p *TerraformPlan) ProviderLiteral(provider string, key string) bool {
+	for _, pc := range p.config.ProviderConfig {
+		if pc.Name != provider {
+			continue
+		}
+		if v := pc.Expressions[key].ConstantValue; len(v) > 0 && string(v) != "null" && string(v) != `""` {
+			return true
+		}
+	}
+	return false
+}
+
+// modulePath returns the module names of a resource configuration address,
+// e.g. [a b] for module.a.module.b.digitalocean_droplet.this.
+func modulePath(addr string) []string {
+	var path []string
+	parts := strings.Split(addr, ".")
+	for i := 0; i+1 < len(parts) && parts[i] == "module"; i += 2 {
+		path = append(path, parts[i+1])
+	}
+	return path
+}
+
+func (c tfConfig) module(path []string) (tfConfigModule, bool) {
+	mod := c.RootModule
+	for _, name := range path {
+		call, ok := mod.ModuleCalls[name]
+		if !ok {
+			return tfConfigModule{}, false
+		}
+		mod = call.Module
+	}
+	return mod, true
+}
+
+// resolve adds to seen the resource addresses that refs, made in the module
+// at path, lead to.
+func (c tfConfig) resolve(path []string, refs []string, seen map[string]bool) {
+	for _, ref := range refs {
+		parts := strings.Split(instanceKeyRe.ReplaceAllString(ref, ""), ".")
+		if len(parts) < 2 {
+			continue
+		}
+		switch parts[0] {
+		case "var":
+			if len(path) == 0 {
+				continue
+			}
+			parent, name := path[:len(path)-1], path[len(path)-1]
+			mod, _ := c.module(parent)
+			c.resolve(parent, mod.ModuleCalls[name].Expressions[parts[1]].References, seen)
+		case "module":
+			if len(parts) < 3 {
+				continue
+			}
+			child := append(slices.Clone(path), parts[1])
+			mod, _ := c.module(child)
+			c.resolve(child, mod.Outputs[parts[2]].Expression.References, seen)
+		case "data", "local", "each", "count", "path", "self", "terraform":
+		default:
+			var prefix strings.Builder
+			for _, name := range path {
+				prefix.WriteString("module." + name + ".")
+			}
+			seen[prefix.String()+parts[0]+"."+parts[1]] = true
+		}
+	}
+}
+
+// StateResourceTypes are the resource types `cisctl drift` compares with
+// DigitalOcean: those of the terraform/modules used by envs/demo.
+var StateResourceTypes = []string{
+	"digitalocean_droplet",
+	"digitalocean_firewall",
+	"digitalocean_volume",
+	"digitalocean_vpc",
+	"digitalocean_spaces_bucket",
+	"digitalocean_monitor_alert",
+}
+
+// TerraformState is the applied state of a Terraform configuration.
+type TerraformState struct {
+	Path string
+	// Resources are the instances of StateResourceTypes.
+	Resources []TerraformResource
+}
+
+type tfStateJSON struct {
+	// Raw state files (terraform.tfstate, terraform state pull).
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   any            `json:"index_key"`
+			Attributes map[string]any `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+	// `terraform show -json` without a plan file.
+	FormatVersion string `json:"format_version"`
+	Values        *struct {
+		RootModule tfStateModule `json:"root_module"`
+	} `json:"values"`
+}
+
+type tfStateModule struct {
+	Resources []struct {
+		Address string         `json:"address"`
+		Mode    string         `json:"mode"`
+		Type    string         `json:"type"`
+		Name    string         `json:"name"`
+		Values  map[string]any `json:"values"`
+	} `json:"resources"`
+	ChildModules []tfStateModule `json:"child_modules"`
+}
+
+// LoadTerraformState reads a state file as written by Terraform or pulled
+// with `terraform state pull`, or the output of `terraform show -json`.
+func LoadTerraformState(path string) (*TerraformState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw tfStateJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	st := &TerraformState{Path: path}
+	add := func(address string, mode string, typ string, name string, values map[string]any) {
+		if mode == "managed" && slices.Contains(StateResourceTypes, typ) {
+			st.Resources = append(st.Resources, TerraformResource{Address: address, Type: typ, Name: name, Values: values})
+		}
+	}
+	switch {
+	case raw.Version == 4:
+		for _, r := range raw.Resources {
+			prefix := ""
+			if r.Module != "" {
+				prefix = r.Module + "."
+			}
+			for _, inst := range r.Instances {
+				add(prefix+r.Type+"."+r.Name+instanceKey(inst.IndexKey), r.Mode, r.Type, r.Name, inst.Attributes)
+			}
+		}
+	case raw.FormatVersion != "" && raw.Values != nil:
+		var walk func(m tfStateModule)
+		walk = func(m tfStateModule) {
+			for _, r := range m.Resources {
+				add(r.Address, r.Mode, r.Type, r.Name, r.Values)
+			}
+			for _, child := range m.ChildModules {
+				walk(child)
+			}
+		}
+		walk(raw.Values.RootModule)
+	case raw.Version != 0:
+		return nil, fmt.Errorf("%s: unsupported state version %d (want 4)", path, raw.Version)
+	default:
+		return nil, fmt.Errorf("%s: not a Terraform state file", path)
+	}
+	return st, nil
+}
+
+// instanceKey renders a count or for_each key as in resource addresses.
+func instanceKey(key any) string {
+	switch key := key.(type) {
+	case float64:
+		return fmt.Sprintf("[%d]", int(key))
+	case string:
+		return fmt.Sprintf("[%q]", key)
+	default:
+		return ""
+	}
+}
+
+// ResourcesOfType returns the state's instances of a resource type.
+func (s *TerraformState) ResourcesOfType(typ string) []TerraformResource {
+	var out []TerraformResource
+	for _, r := range s.Resources {
+		if r.Type == typ {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package cisctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TokenSourceEnv     = "env"
+	TokenSourceFile    = "file"
+	TokenSourceCommand = "command"
+	TokenSourceDoctl   = "doctl"
+)
+
+// TokenSource says where the DO access token comes from. Ref is the variable
+// name, file path, shell command or doctl context, depending on Kind.
+type TokenSource struct {
+	Kind string
+	Ref  string
+}
+
+// String describes the source for logs and diagnostics; it never includes the
+// token.
+func (s TokenSource) String() string {
+	switch s.Kind {
+	case "":
+		return "none"
+	case TokenSourceDoctl:
+		return "doctl context " + firstNonEmpty(s.Ref, "(current)")
+	case TokenSourceCommand:
+		return fmt.Sprintf("command %q", s.Ref)
+	default:
+		return s.Kind + " " + s.Ref
+	}
+}
+
+// Token reads the token from the source. Environment sources are resolved by
+// LoadConfig and have nothing to read here.
+func (s TokenSource) Token(ctx context.Context) (string, error) {
+	switch s.Kind {
+	case TokenSourceFile:
+		return readTokenFile(s.Ref)
+	case TokenSourceCommand:
+		return runTokenCommand(ctx, s.Ref)
+	case TokenSourceDoctl:
+		return doctlToken(DoctlConfigPath(), s.Ref)
+	default:
+		return "", fmt.Errorf("token source %s cannot be read", s)
+	}
+}
+
+// readTokenFile refuses files that group or others can read, like ssh does
+// for private keys.
+func readTokenFile(path string) (string, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && st.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("token file %s is accessible by group or others (mode %04o); run chmod 600 %s", path, st.Mode().Perm(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// runTokenCommand runs a credential helper such as `pass show do/token` and
+// uses the first line of its output. The helper's stderr is passed through so
+// it can prompt; its stdout is never echoed.
+func runTokenCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+	token, _, _ := strings.Cut(stdout.String(), "\n")
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("token command printed no token")
+	}
+	return token, nil
+}
+
+// DoctlConfigPath returns doctl's config file: DOCTL_CONFIG, or config.yaml in
+// doctl's directory under the user config dir.
+func DoctlConfigPath() string {
+	if v := strings.TrimSpace(os.Getenv("DOCTL_CONFIG")); v != "" {
+		return v
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "doctl", "config.yaml")
+}
+
+// doctlToken reads a token from doctl's config. The "default" context uses
+// access-token; other contexts come from auth-contexts. An empty context
+// means the one doctl currently uses.
+func doctlToken(path string, contextName string) (string, error) {
+	if path == "" {
+		return "", errors.New("cannot locate the doctl config directory")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		AccessToken  string            `yaml:"access-token"`
+		AuthContexts map[string]string `yaml:"auth-contexts"`
+		Context      string            `yaml:"context"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	name := firstNonEmpty(contextName, doc.Context, "default")
+	token := doc.AuthContexts[name]
+	if name == "default" {
+		token = firstNonEmpty(doc.AccessToken, token)
+	}
+	if strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("%s: no token for doctl context %q (run doctl auth init --context %s)", path, name, name)
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// resolveToken fills DOAccessToken from TokenSource when it was not set
+// directly in the environment.
+func (c *Config) resolveToken(ctx context.Context) error {
+	if c.DOAccessToken != "" || c.TokenSource.Kind == "" {
+		return nil
+	}
+	token, err := c.TokenSource.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("read DO token from %s: %w", c.TokenSource, err)
+	}
+	c.DOAccessToken = token
+	return nil
+}
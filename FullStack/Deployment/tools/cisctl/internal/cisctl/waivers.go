@@ -0,0 +1,157 @@
+package cisctl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Waiver accepts the risk of failing findings of one control. It matches a
+// finding by resource ID, by resource name glob (path.Match syntax) or by a
+// droplet tag; every selector that is set must match.
+type Waiver struct {
+	Control       string `yaml:"control"`
+	ResourceID    string `yaml:"resource_id"`
+	ResourceName  string `yaml:"resource_name"`
+	Tag           string `yaml:"tag"`
+	Justification string `yaml:"justification"`
+	Approver      string `yaml:"approver"`
+	// Expires is a YYYY-MM-DD date; the waiver applies through that day (UTC).
+	Expires string `yaml:"expires"`
+
+	expiresAt time.Time
+}
+
+type WaiverSet struct {
+	Path    string
+	Waivers []Waiver
+}
+
+// LoadWaivers reads and validates a waivers file:
+//
+//	waivers:
+//	  - control: "2.1.3"
+//	    resource_name: "bastion-*"
+//	    justification: "Bastion is reachable on 22 by design"
+//	    approver: "security@example.com"
+//	    expires: 2026-06-30
+func LoadWaivers(filePath string) (*WaiverSet, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Waivers []Waiver `yaml:"waivers"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	for i := range doc.Waivers {
+		w := &doc.Waivers[i]
+		if err := w.validate(); err != nil {
+			return nil, fmt.Errorf("%s: waiver #%d (control %q): %w", filePath, i+1, w.Control, err)
+		}
+	}
+	return &WaiverSet{Path: filePath, Waivers: doc.Waivers}, nil
+}
+
+func (w *Waiver) validate() error {
+	switch {
+	case w.Control == "":
+		return errors.New("missing control")
+	case w.ResourceID == "" && w.ResourceName == "" && w.Tag == "":
+		return errors.New("set at least one of resource_id, resource_name or tag")
+	case w.Justification == "":
+		return errors.New("missing justification")
+	case w.Approver == "":
+		return errors.New("missing approver")
+	case w.Expires == "":
+		return errors.New("missing expires")
+	}
+	if _, err := path.Match(w.ResourceName, ""); err != nil {
+		return fmt.Errorf("invalid resource_name pattern %q", w.ResourceName)
+	}
+	day, err := time.Parse("2006-01-02", w.Expires)
+	if err != nil {
+		return fmt.Errorf("expires %q is not a YYYY-MM-DD date", w.Expires)
+	}
+	w.expiresAt = day.AddDate(0, 0, 1)
+	return nil
+}
+
+func (w Waiver) matches(controlID string, f Finding, tags func(Finding) []string) bool {
+	if w.Control != controlID {
+		return false
+	}
+	if w.ResourceID != "" && w.ResourceID != f.ResourceID {
+		return false
+	}
+	if w.ResourceName != "" {
+		if ok, _ := path.Match(w.ResourceName, f.ResourceName); !ok {
+			return false
+		}
+	}
+	if w.Tag != "" && !HasString(tags(f), w.Tag) {
+		return false
+	}
+	return true
+}
+
+// Apply attaches matching waivers to the failing findings of result and sets
+// its Status and Pass. An unexpired waiver wins over an expired one. Findings
+// that record a control error are never waived. tags returns the tags of a
+// finding's resource and is only called for waivers with a tag selector.
+func (s *WaiverSet) Apply(result *ControlResult, tags func(Finding) []string, now time.Time) {
+	if s != nil {
+		for i := range result.Findings {
+			f := &result.Findings[i]
+			if f.Pass || f.ResourceType == "control" {
+				continue
+			}
+			var match *Waiver
+			for j := range s.Waivers {
+				w := &s.Waivers[j]
+				if !w.matches(result.ControlID, *f, tags) {
+					continue
+				}
+				if match == nil || now.Before(w.expiresAt) && !now.Before(match.expiresAt) {
+					match = w
+				}
+			}
+			if match != nil {
+				f.Waiver = &FindingWaiver{
+					Justification: match.Justification,
+					Approver:      match.Approver,
+					Expires:       match.Expires,
+					Expired:       !now.Before(match.expiresAt),
+				}
+			}
+		}
+	}
+
+	result.Status = StatusPass
+	notApplicable := len(result.Findings) > 0
+	for _, f := range result.Findings {
+		if f.Failing() {
+			result.Status = StatusFail
+			break
+		}
+		if !f.Pass {
+			result.Status = StatusWaived
+		}
+		notApplicable = notApplicable && f.NotApplicable
+	}
+	if notApplicable && result.Status == StatusPass {
+		result.Status = StatusNotApplicable
+	}
+	result.Pass = result.Status != StatusFail
+}
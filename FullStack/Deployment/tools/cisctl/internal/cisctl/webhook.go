@@ -0,0 +1,195 @@
+package cisctl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookModeReport  = "report"
+	WebhookModeFinding = "finding"
+
+	// SignatureHeader carries "sha256=<hex>", the HMAC-SHA256 of the request
+	// body keyed with the shared secret.
+	SignatureHeader = "X-Cisctl-Signature"
+
+	cloudEventsContentType = "application/cloudevents+json"
+	eventTypePrefix        = "io.cisctl."
+)
+
+// CloudEvent is a CloudEvents 1.0 event in structured JSON mode.
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            any       `json:"data"`
+}
+
+// WebhookOptions selects which events are sent for a report.
+type WebhookOptions struct {
+	Mode string
+	// OnlyFailures drops passing findings, and in report mode skips reports
+	// without failures.
+	OnlyFailures bool
+	// Baseline, when set, limits events to findings that changed since that
+	// report (new, resolved or changed evidence).
+	Baseline *Report
+	// SkipEvidence, with Baseline, drops findings whose only change is their
+	// evidence.
+	SkipEvidence bool
+}
+
+// WebhookEndpoint is one destination for events.
+type WebhookEndpoint struct {
+	URL     string
+	Secret  string
+	Headers http.Header
+}
+
+// FindingEventData is the payload of a per-finding event.
+type FindingEventData struct {
+	RunID     string   `json:"run_id,omitempty"`
+	EnvTag    string   `json:"env_tag"`
+	ControlID string   `json:"control_id"`
+	Title     string   `json:"title"`
+	Status    string   `json:"status"`
+	Finding   Finding  `json:"finding"`
+	Previous  *Finding `json:"previous,omitempty"`
+}
+
+// BuildWebhookEvents turns report into CloudEvents according to opts. It
+// returns no events when the filters leave nothing to send.
+func BuildWebhookEvents(report Report, opts WebhookOptions) []CloudEvent {
+	source := "urn:cisctl:" + report.EnvTag
+	newEvent := func(typ string, subject string, data any) CloudEvent {
+		return CloudEvent{
+			SpecVersion:     "1.0",
+			ID:              NewRunID(),
+			Source:          source,
+			Type:            eventTypePrefix + typ,
+			Subject:         subject,
+			Time:            report.Timestamp,
+			DataContentType: "application/json",
+			Data:            data,
+		}
+	}
+
+	var diff *ReportDiff
+	if opts.Baseline != nil {
+		d := DiffReports(*opts.Baseline, report)
+		if opts.SkipEvidence {
+			d = d.withoutEvidenceChanges()
+		}
+		diff = &d
+	}
+
+	if opts.Mode == WebhookModeReport {
+		if opts.OnlyFailures && report.Summary.Fail == 0 {
+			return nil
+		}
+		if diff != nil && diff.Summary.New+diff.Summary.Resolved+diff.Summary.ChangedEvidence == 0 {
+			return nil
+		}
+		return []CloudEvent{newEvent("report.completed", report.RunID, report)}
+	}
+
+	titles := map[string]string{}
+	for _, r := range report.Results {
+		titles[r.ControlID] = r.Title
+	}
+
+	var events []CloudEvent
+	if diff != nil {
+		for _, it := range diff.Items {
+			if it.Status == DiffPersisting {
+				continue
+			}
+			data := FindingEventData{
+				RunID:     report.RunID,
+				EnvTag:    report.EnvTag,
+				ControlID: it.ControlID,
+				Title:     titles[it.ControlID],
+				Status:    it.Status,
+				Previous:  it.Old,
+			}
+			if it.New != nil {
+				data.Finding = *it.New
+			} else {
+				data.Finding = *it.Old
+			}
+			if opts.OnlyFailures && !data.Finding.Failing() {
+				continue
+			}
+			events = append(events, newEvent("finding."+it.Status, findingSubject(it.ControlID, data.Finding), data))
+		}
+		return events
+	}
+
+	for _, r := range report.Results {
+		for _, f := range r.Findings {
+			if opts.OnlyFailures && !f.Failing() {
+				continue
+			}
+			status := "fail"
+			switch {
+			case f.NotApplicable:
+				status = "not_applicable"
+			case f.Pass:
+				status = "pass"
+			case !f.Failing():
+				status = "waived"
+			}
+			events = append(events, newEvent("finding."+status, findingSubject(r.ControlID, f), FindingEventData{
+				RunID:     report.RunID,
+				EnvTag:    report.EnvTag,
+				ControlID: r.ControlID,
+				Title:     r.Title,
+				Status:    status,
+				Finding:   f,
+			}))
+		}
+	}
+	return events
+}
+
+func findingSubject(controlID string, f Finding) string {
+	resource := f.ResourceID
+	if resource == "" {
+		resource = f.ResourceName
+	}
+	return strings.TrimSuffix(controlID+"/"+f.ResourceType+"/"+resource, "/")
+}
+
+// SignPayload returns the SignatureHeader value for body.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SendWebhookEvent posts ev to ep, signing the body when ep has a secret.
+func SendWebhookEvent(ctx context.Context, client *http.Client, ep WebhookEndpoint, ev CloudEvent, retries int) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	header := ep.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", cloudEventsContentType)
+	if ep.Secret != "" {
+		header.Set(SignatureHeader, SignPayload(ep.Secret, body))
+	}
+	return postWithRetry(ctx, client, ep.URL, header, body, retries)
+}
@@ -10,6 +10,21 @@ func All() []cisctl.Control {
 		Droplet214OSUpgrade{},
 		Droplet215OSUpdate{},
 		Droplet216AuditdEnabled{},
+		Droplet217OnlySSHKey{},
+		Monitoring222EnableMonitoring{},
 	}
 }
 
+// Plan returns the checks of `cisctl scan-plan`.
+func Plan() []cisctl.PlanControl {
+	return []cisctl.PlanControl{
+		Droplet211Backups{},
+		Droplet212FirewallCreated{},
+		Droplet213ConnectFirewall{},
+		Monitoring222EnableMonitoring{},
+		Spaces232AccessKeys{},
+		Spaces233Lifecycle{},
+		Spaces234PrivateAccess{},
+		Spaces235CDN{},
+	}
+}
@@ -0,0 +1,8 @@
+package controls
+
+// DigitalOcean API requests, as listed by `cisctl describe`.
+const (
+	apiListDroplets      = "GET /v2/droplets?tag_name=<env_tag> (godo Droplets.ListByTag)"
+	apiListFirewalls     = "GET /v2/firewalls (godo Firewalls.List)"
+	apiListAlertPolicies = "GET /v2/monitoring/alerts (godo Monitoring.ListAlertPolicies)"
+)
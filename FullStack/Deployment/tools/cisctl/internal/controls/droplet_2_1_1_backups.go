@@ -15,7 +15,7 @@ func (Droplet211Backups) Title() string { return "Ensure Backups are Enabled" }
 func (Droplet211Backups) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
 	var out cisctl.ControlOutcome
 
-	droplets, err := deps.DO.ListDropletsByTag(ctx, deps.Config.EnvTag)
+	droplets, err := deps.Droplets(ctx)
 	if err != nil {
 		return out, err
 	}
@@ -24,7 +24,7 @@ func (Droplet211Backups) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Cont
 		out.Findings = append(out.Findings, cisctl.Finding{
 			ResourceType: "droplet",
 			Pass:         false,
-			Reason:       fmt.Sprintf("No droplets found with tag %s", deps.Config.EnvTag),
+			Reason:       fmt.Sprintf("No droplets found for %s", deps.Config.ScopeString()),
 		})
 		return out, nil
 	}
@@ -41,10 +41,10 @@ func (Droplet211Backups) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Cont
 		if !hasBackups {
 			f.Reason = "Backups disabled"
 			if deps.Log != nil {
-				deps.Log.Errorf("%s backups disabled (id=%d)", d.Name, d.ID)
+				deps.Log.WithFinding(f).Error("backups disabled")
 			}
 		} else if deps.Log != nil {
-			deps.Log.Infof("%s backups enabled (id=%d)", d.Name, d.ID)
+			deps.Log.WithFinding(f).Info("backups enabled")
 		}
 		out.Findings = append(out.Findings, f)
 	}
@@ -52,3 +52,48 @@ func (Droplet211Backups) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Cont
 	return out, nil
 }
 
+func (Droplet211Backups) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet"},
+		APICalls:      []string{apiListDroplets},
+	}
+}
+
+func (Droplet211Backups) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	var steps []cisctl.RemediationStep
+	findings, ids := failedDroplets(result)
+	for i, f := range findings {
+		id := ids[i]
+		steps = append(steps, cisctl.RemediationStep{
+			Key:      "backups:" + f.ResourceID,
+			Resource: dropletLabel(f),
+			Action:   "enable backups",
+			Apply: func(ctx context.Context) error {
+				return deps.DO.EnableDropletBackups(ctx, id)
+			},
+		})
+	}
+	return steps, nil
+}
+
+func (Droplet211Backups) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	droplets := plan.ResourcesOfType(tfDroplet)
+	if len(droplets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("droplet", tfDroplet))
+		return out
+	}
+	for _, d := range droplets {
+		f := planFinding("droplet", d)
+		backups, known := d.Bool("backups")
+		f.Pass = backups
+		switch {
+		case !known:
+			f.Reason = "Backups not known until apply"
+		case !backups:
+			f.Reason = "Backups disabled"
+		}
+		out.Findings = append(out.Findings, f)
+	}
+	return out
+}
@@ -15,7 +15,7 @@ func (Droplet212FirewallCreated) Title() string { return "Ensure a Firewall is C
 func (Droplet212FirewallCreated) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
 	var out cisctl.ControlOutcome
 
-	droplets, err := deps.DO.ListDropletsByTag(ctx, deps.Config.EnvTag)
+	droplets, err := deps.Droplets(ctx)
 	if err != nil {
 		return out, err
 	}
@@ -23,7 +23,7 @@ func (Droplet212FirewallCreated) Run(ctx context.Context, deps cisctl.Deps) (cis
 		out.Findings = append(out.Findings, cisctl.Finding{
 			ResourceType: "droplet",
 			Pass:         false,
-			Reason:       fmt.Sprintf("No droplets found with tag %s", deps.Config.EnvTag),
+			Reason:       fmt.Sprintf("No droplets found for %s", deps.Config.ScopeString()),
 		})
 		return out, nil
 	}
@@ -34,24 +34,60 @@ func (Droplet212FirewallCreated) Run(ctx context.Context, deps cisctl.Deps) (cis
 	}
 
 	for _, d := range droplets {
-		covered := dropletFirewallCovered(d.ID, deps.Config.EnvTag, firewalls)
+		covered := dropletFirewallCovered(d, firewalls)
 		f := cisctl.Finding{
 			ResourceType: "droplet",
 			ResourceID:   fmt.Sprintf("%d", d.ID),
 			ResourceName: d.Name,
 			IP:           cisctl.DropletPublicIPv4(d),
 			Pass:         covered,
+			Evidence: map[string]string{
+				"firewall_covered": yesNo(covered),
+			},
 		}
 		if !covered {
 			f.Reason = "No firewall attached"
 			if deps.Log != nil {
-				deps.Log.Errorf("%s not protected by any firewall (id=%d)", d.Name, d.ID)
+				deps.Log.WithFinding(f).Error("not protected by any firewall")
 			}
 		} else if deps.Log != nil {
-			deps.Log.Infof("%s firewall coverage OK (id=%d)", d.Name, d.ID)
+			deps.Log.WithFinding(f).Info("firewall coverage OK")
 		}
 		out.Findings = append(out.Findings, f)
 	}
 
 	return out, nil
 }
+
+func (Droplet212FirewallCreated) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet"},
+		APICalls:      []string{apiListDroplets, apiListFirewalls},
+		EvidenceKeys:  []string{"firewall_covered"},
+	}
+}
+
+func (Droplet212FirewallCreated) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	return planFirewallCoverage(ctx, deps, result)
+}
+
+func (Droplet212FirewallCreated) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	droplets := plan.ResourcesOfType(tfDroplet)
+	if len(droplets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("droplet", tfDroplet))
+		return out
+	}
+	for _, d := range droplets {
+		covered := planFirewallCovered(plan, d)
+		f := planFinding("droplet", d)
+		f.Pass = covered
+		f.Evidence["firewall_covered"] = yesNo(covered)
+		if !covered {
+			f.Reason = "No firewall in plan targets droplet"
+		}
+		out.Findings = append(out.Findings, f)
+	}
+	out.Notes = "Firewalls outside the plan are not considered"
+	return out
+}
@@ -16,7 +16,7 @@ func (Droplet213ConnectFirewall) Title() string { return "Ensure Droplets are Co
 func (Droplet213ConnectFirewall) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
 	var out cisctl.ControlOutcome
 
-	droplets, err := deps.DO.ListDropletsByTag(ctx, deps.Config.EnvTag)
+	droplets, err := deps.Droplets(ctx)
 	if err != nil {
 		return out, err
 	}
@@ -24,7 +24,7 @@ func (Droplet213ConnectFirewall) Run(ctx context.Context, deps cisctl.Deps) (cis
 		out.Findings = append(out.Findings, cisctl.Finding{
 			ResourceType: "droplet",
 			Pass:         false,
-			Reason:       fmt.Sprintf("No droplets found with tag %s", deps.Config.EnvTag),
+			Reason:       fmt.Sprintf("No droplets found for %s", deps.Config.ScopeString()),
 		})
 		return out, nil
 	}
@@ -41,7 +41,8 @@ func (Droplet213ConnectFirewall) Run(ctx context.Context, deps cisctl.Deps) (cis
 		if vpcUUID == "" {
 			reasons = append(reasons, "No VPC attached")
 		}
-		if !dropletFirewallCovered(d.ID, deps.Config.EnvTag, firewalls) {
+		covered := dropletFirewallCovered(d, firewalls)
+		if !covered {
 			reasons = append(reasons, "No firewall attached")
 		}
 
@@ -53,16 +54,17 @@ func (Droplet213ConnectFirewall) Run(ctx context.Context, deps cisctl.Deps) (cis
 			IP:           cisctl.DropletPublicIPv4(d),
 			Pass:         pass,
 			Evidence: map[string]string{
-				"vpc_uuid": vpcUUID,
+				"vpc_uuid":         vpcUUID,
+				"firewall_covered": yesNo(covered),
 			},
 		}
 		if !pass {
 			f.Reason = strings.Join(reasons, "; ")
 			if deps.Log != nil {
-				deps.Log.Errorf("%s: %s (id=%d)", d.Name, f.Reason, d.ID)
+				deps.Log.WithFinding(f).Error("not connected to VPC+Firewall", "reason", f.Reason)
 			}
 		} else if deps.Log != nil {
-			deps.Log.Infof("%s connected to VPC+Firewall (id=%d)", d.Name, d.ID)
+			deps.Log.WithFinding(f).Info("connected to VPC+Firewall")
 		}
 
 		out.Findings = append(out.Findings, f)
@@ -71,3 +73,59 @@ func (Droplet213ConnectFirewall) Run(ctx context.Context, deps cisctl.Deps) (cis
 	return out, nil
 }
 
+func (Droplet213ConnectFirewall) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet"},
+		APICalls:      []string{apiListDroplets, apiListFirewalls},
+		EvidenceKeys:  []string{"vpc_uuid", "firewall_covered"},
+	}
+}
+
+func (Droplet213ConnectFirewall) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	droplets := plan.ResourcesOfType(tfDroplet)
+	if len(droplets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("droplet", tfDroplet))
+		return out
+	}
+	for _, d := range droplets {
+		reasons := []string{}
+
+		vpc, hasVPC := planVPC(plan, d)
+		if !hasVPC {
+			reasons = append(reasons, "No VPC set")
+		}
+		covered := planFirewallCovered(plan, d)
+		if !covered {
+			reasons = append(reasons, "No firewall in plan targets droplet")
+		}
+
+		f := planFinding("droplet", d)
+		f.Pass = len(reasons) == 0
+		f.Reason = strings.Join(reasons, "; ")
+		f.Evidence["vpc_uuid"] = vpc
+		f.Evidence["firewall_covered"] = yesNo(covered)
+		out.Findings = append(out.Findings, f)
+	}
+	out.Notes = "Firewalls outside the plan are not considered"
+	return out
+}
+
+func (Droplet213ConnectFirewall) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	steps, err := planFirewallCoverage(ctx, deps, result)
+	if err != nil {
+		return nil, err
+	}
+	findings, _ := failedDroplets(result)
+	for _, f := range findings {
+		if f.Evidence["vpc_uuid"] != "" {
+			continue
+		}
+		steps = append(steps, cisctl.RemediationStep{
+			Key:      "vpc:" + f.ResourceID,
+			Resource: dropletLabel(f),
+			Action:   "a droplet cannot be moved into a VPC in place; recreate it with vpc_uuid set (Terraform module droplet)",
+		})
+	}
+	return steps, nil
+}
@@ -13,56 +13,71 @@ type Droplet214OSUpgrade struct{}
 func (Droplet214OSUpgrade) ID() string    { return "2.1.4" }
 func (Droplet214OSUpgrade) Title() string { return "Ensure OS Upgrade Policy (unattended-upgrades enabled)" }
 
+func (Droplet214OSUpgrade) HostControl() {}
+
 func (Droplet214OSUpgrade) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
 	out := cisctl.ControlOutcome{
 		Notes: "Manual evidence of major/minor upgrade policy may still be required.",
 	}
 
-	droplets, err := deps.DO.ListDropletsByTag(ctx, deps.Config.EnvTag)
+	hosts, err := deps.Hosts(ctx)
 	if err != nil {
 		return out, err
 	}
-	if len(droplets) == 0 {
-		out.Findings = append(out.Findings, cisctl.Finding{
-			ResourceType: "droplet",
-			Pass:         false,
-			Reason:       fmt.Sprintf("No droplets found with tag %s", deps.Config.EnvTag),
-		})
+	if len(hosts) == 0 {
+		out.Findings = append(out.Findings, noHostsFinding(deps))
 		return out, nil
 	}
 
-	for _, d := range droplets {
-		ip := cisctl.DropletPublicIPv4(d)
+	for _, h := range hosts {
+		ip := h.Address
 		if strings.TrimSpace(ip) == "" {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				Pass:         false,
 				Reason:       "No public IPv4 address",
 			})
 			continue
 		}
 
-		pkg, err := sshCommand(deps, ip, `dpkg -s unattended-upgrades >/dev/null 2>&1 && echo yes || echo no`)
+		osr, distro, ok, err := detectDistro(deps, h)
 		if err != nil {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				IP:           ip,
 				Pass:         false,
 				Reason:       fmt.Sprintf("SSH unreachable: %v", err),
 			})
 			continue
 		}
+		if !ok {
+			out.Findings = append(out.Findings, notApplicableFinding(h, osr))
+			continue
+		}
+
+		pkg, err := sshCommand(deps, h, distro.UpdatesInstalled)
+		if err != nil {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				IP:           ip,
+				Pass:         false,
+				Reason:       fmt.Sprintf("SSH command failed: %v", err),
+			})
+			continue
+		}
 
-		enabled, err := sshCommand(deps, ip, `grep -Eq 'APT::Periodic::Unattended-Upgrade\s+"1"' /etc/apt/apt.conf.d/20auto-upgrades 2>/dev/null && echo yes || echo no`)
+		enabled, err := sshCommand(deps, h, distro.UpdatesApply)
 		if err != nil {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				IP:           ip,
 				Pass:         false,
 				Reason:       fmt.Sprintf("SSH command failed: %v", err),
@@ -72,20 +87,22 @@ func (Droplet214OSUpgrade) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Co
 
 		reasons := []string{}
 		if pkg != "yes" {
-			reasons = append(reasons, "unattended-upgrades not installed")
+			reasons = append(reasons, distro.UpdatesPackage+" not installed")
 		}
 		if enabled != "yes" {
-			reasons = append(reasons, "unattended-upgrades not enabled")
+			reasons = append(reasons, distro.UpdatesPackage+" not enabled")
 		}
 
 		pass := len(reasons) == 0
 		f := cisctl.Finding{
-			ResourceType: "droplet",
-			ResourceID:   fmt.Sprintf("%d", d.ID),
-			ResourceName: d.Name,
+			ResourceType: h.ResourceType,
+			ResourceID:   h.ResourceID,
+			ResourceName: h.Name,
 			IP:           ip,
 			Pass:         pass,
 			Evidence: map[string]string{
+				"os":            osr.String(),
+				"os_family":     distro.Family,
 				"pkg_installed": pkg,
 				"enabled":       enabled,
 			},
@@ -93,10 +110,10 @@ func (Droplet214OSUpgrade) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Co
 		if !pass {
 			f.Reason = strings.Join(reasons, "; ")
 			if deps.Log != nil {
-				deps.Log.Errorf("%s: %s", d.Name, f.Reason)
+				deps.Log.WithFinding(f).Error("upgrade policy check failed", "reason", f.Reason)
 			}
 		} else if deps.Log != nil {
-			deps.Log.Infof("%s: unattended-upgrades installed+enabled", d.Name)
+			deps.Log.WithFinding(f).Info(distro.UpdatesPackage + " installed+enabled")
 		}
 
 		out.Findings = append(out.Findings, f)
@@ -105,3 +122,17 @@ func (Droplet214OSUpgrade) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Co
 	return out, nil
 }
 
+func (Droplet214OSUpgrade) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet", "host"},
+		APICalls:      []string{apiListDroplets},
+		Commands: distroCommands(func(d hostDistro) []string {
+			return []string{d.UpdatesInstalled, d.UpdatesApply}
+		}),
+		EvidenceKeys: []string{"os", "os_family", "pkg_installed", "enabled"},
+	}
+}
+
+func (Droplet214OSUpgrade) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	return planHostRemediation(deps, result, "auto-updates", updatesFix), nil
+}
@@ -13,94 +13,113 @@ type Droplet215OSUpdate struct{}
 func (Droplet215OSUpdate) ID() string    { return "2.1.5" }
 func (Droplet215OSUpdate) Title() string { return "Ensure Periodic Security Updates are Configured" }
 
+func (Droplet215OSUpdate) HostControl() {}
+
 func (Droplet215OSUpdate) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
 	var out cisctl.ControlOutcome
 
-	droplets, err := deps.DO.ListDropletsByTag(ctx, deps.Config.EnvTag)
+	hosts, err := deps.Hosts(ctx)
 	if err != nil {
 		return out, err
 	}
-	if len(droplets) == 0 {
-		out.Findings = append(out.Findings, cisctl.Finding{
-			ResourceType: "droplet",
-			Pass:         false,
-			Reason:       fmt.Sprintf("No droplets found with tag %s", deps.Config.EnvTag),
-		})
+	if len(hosts) == 0 {
+		out.Findings = append(out.Findings, noHostsFinding(deps))
 		return out, nil
 	}
 
-	for _, d := range droplets {
-		ip := cisctl.DropletPublicIPv4(d)
+	for _, h := range hosts {
+		ip := h.Address
 		if strings.TrimSpace(ip) == "" {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				Pass:         false,
 				Reason:       "No public IPv4 address",
 			})
 			continue
 		}
 
-		pkg, err := sshCommand(deps, ip, `dpkg -s unattended-upgrades >/dev/null 2>&1 && echo yes || echo no`)
+		osr, distro, ok, err := detectDistro(deps, h)
 		if err != nil {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				IP:           ip,
 				Pass:         false,
 				Reason:       fmt.Sprintf("SSH unreachable: %v", err),
 			})
 			continue
 		}
+		if !ok {
+			out.Findings = append(out.Findings, notApplicableFinding(h, osr))
+			continue
+		}
+
+		pkg, err := sshCommand(deps, h, distro.UpdatesInstalled)
+		if err != nil {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				IP:           ip,
+				Pass:         false,
+				Reason:       fmt.Sprintf("SSH command failed: %v", err),
+			})
+			continue
+		}
 
-		updateLists, _ := sshCommand(deps, ip, `grep -Eq 'APT::Periodic::Update-Package-Lists\s+"1"' /etc/apt/apt.conf.d/20auto-upgrades 2>/dev/null && echo yes || echo no`)
-		unattended, _ := sshCommand(deps, ip, `grep -Eq 'APT::Periodic::Unattended-Upgrade\s+"1"' /etc/apt/apt.conf.d/20auto-upgrades 2>/dev/null && echo yes || echo no`)
-		timersEnabled, _ := sshCommand(deps, ip, `systemctl is-enabled apt-daily.timer apt-daily-upgrade.timer >/dev/null 2>&1 && echo yes || echo no`)
-		timersActive, _ := sshCommand(deps, ip, `systemctl is-active apt-daily.timer apt-daily-upgrade.timer >/dev/null 2>&1 && echo yes || echo no`)
+		updateLists, _ := sshCommand(deps, h, distro.UpdatesRefresh)
+		unattended, _ := sshCommand(deps, h, distro.UpdatesApply)
+		timersEnabled, _ := sshCommand(deps, h, distro.UpdatesTimersEnabled)
+		timersActive, _ := sshCommand(deps, h, distro.UpdatesTimersActive)
 
 		reasons := []string{}
 		if pkg != "yes" {
-			reasons = append(reasons, "unattended-upgrades not installed")
+			reasons = append(reasons, distro.UpdatesPackage+" not installed")
 		}
 		if updateLists != "yes" {
-			reasons = append(reasons, "Update-Package-Lists not enabled")
+			reasons = append(reasons, distro.UpdatesRefreshLabel+" not enabled")
 		}
 		if unattended != "yes" {
-			reasons = append(reasons, "Unattended-Upgrade not enabled")
+			reasons = append(reasons, distro.UpdatesApplyLabel+" not enabled")
 		}
 		if timersEnabled != "yes" {
-			reasons = append(reasons, "apt-daily timers not enabled")
+			reasons = append(reasons, distro.UpdatesTimersLabel+" not enabled")
 		}
 		if timersActive != "yes" {
-			reasons = append(reasons, "apt-daily timers not active")
+			reasons = append(reasons, distro.UpdatesTimersLabel+" not active")
 		}
 
 		pass := len(reasons) == 0
 		f := cisctl.Finding{
-			ResourceType: "droplet",
-			ResourceID:   fmt.Sprintf("%d", d.ID),
-			ResourceName: d.Name,
+			ResourceType: h.ResourceType,
+			ResourceID:   h.ResourceID,
+			ResourceName: h.Name,
 			IP:           ip,
 			Pass:         pass,
 			Evidence: map[string]string{
-				"pkg_installed":         pkg,
-				"update_package_lists":  updateLists,
-				"unattended_upgrade":    unattended,
-				"timers_enabled":        timersEnabled,
-				"timers_active":         timersActive,
-				"20auto_upgrades_found": "yes",
+				"os":                   osr.String(),
+				"os_family":            distro.Family,
+				"pkg_installed":        pkg,
+				"update_package_lists": updateLists,
+				"unattended_upgrade":   unattended,
+				"timers_enabled":       timersEnabled,
+				"timers_active":        timersActive,
 			},
 		}
+		if distro.Family == debianDistro.Family {
+			f.Evidence["20auto_upgrades_found"] = "yes"
+		}
 
 		if !pass {
 			f.Reason = strings.Join(reasons, "; ")
 			if deps.Log != nil {
-				deps.Log.Errorf("%s: %s", d.Name, f.Reason)
+				deps.Log.WithFinding(f).Error("periodic updates check failed", "reason", f.Reason)
 			}
 		} else if deps.Log != nil {
-			deps.Log.Infof("%s: periodic updates configured", d.Name)
+			deps.Log.WithFinding(f).Info("periodic updates configured")
 		}
 
 		out.Findings = append(out.Findings, f)
@@ -109,3 +128,26 @@ func (Droplet215OSUpdate) Run(ctx context.Context, deps cisctl.Deps) (cisctl.Con
 	return out, nil
 }
 
+func (Droplet215OSUpdate) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet", "host"},
+		APICalls:      []string{apiListDroplets},
+		Commands: distroCommands(func(d hostDistro) []string {
+			return []string{d.UpdatesInstalled, d.UpdatesRefresh, d.UpdatesApply, d.UpdatesTimersEnabled, d.UpdatesTimersActive}
+		}),
+		EvidenceKeys: []string{
+			"os",
+			"os_family",
+			"pkg_installed",
+			"update_package_lists",
+			"unattended_upgrade",
+			"timers_enabled",
+			"timers_active",
+			"20auto_upgrades_found",
+		},
+	}
+}
+
+func (Droplet215OSUpdate) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	return planHostRemediation(deps, result, "auto-updates", updatesFix), nil
+}
@@ -13,54 +13,69 @@ type Droplet216AuditdEnabled struct{}
 func (Droplet216AuditdEnabled) ID() string    { return "2.1.6" }
 func (Droplet216AuditdEnabled) Title() string { return "Ensure auditd is Enabled" }
 
+func (Droplet216AuditdEnabled) HostControl() {}
+
 func (Droplet216AuditdEnabled) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
 	var out cisctl.ControlOutcome
 
-	droplets, err := deps.DO.ListDropletsByTag(ctx, deps.Config.EnvTag)
+	hosts, err := deps.Hosts(ctx)
 	if err != nil {
 		return out, err
 	}
-	if len(droplets) == 0 {
-		out.Findings = append(out.Findings, cisctl.Finding{
-			ResourceType: "droplet",
-			Pass:         false,
-			Reason:       fmt.Sprintf("No droplets found with tag %s", deps.Config.EnvTag),
-		})
+	if len(hosts) == 0 {
+		out.Findings = append(out.Findings, noHostsFinding(deps))
 		return out, nil
 	}
 
-	for _, d := range droplets {
-		ip := cisctl.DropletPublicIPv4(d)
+	for _, h := range hosts {
+		ip := h.Address
 		if strings.TrimSpace(ip) == "" {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				Pass:         false,
 				Reason:       "No public IPv4 address",
 			})
 			continue
 		}
 
-		pkg, err := sshCommand(deps, ip, `dpkg -s auditd >/dev/null 2>&1 && echo yes || echo no`)
+		osr, distro, ok, err := detectDistro(deps, h)
 		if err != nil {
 			out.Findings = append(out.Findings, cisctl.Finding{
-				ResourceType: "droplet",
-				ResourceID:   fmt.Sprintf("%d", d.ID),
-				ResourceName: d.Name,
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
 				IP:           ip,
 				Pass:         false,
 				Reason:       fmt.Sprintf("SSH unreachable: %v", err),
 			})
 			continue
 		}
+		if !ok {
+			out.Findings = append(out.Findings, notApplicableFinding(h, osr))
+			continue
+		}
 
-		enabled, _ := sshCommand(deps, ip, `systemctl is-enabled auditd >/dev/null 2>&1 && echo yes || echo no`)
-		active, _ := sshCommand(deps, ip, `systemctl is-active auditd >/dev/null 2>&1 && echo yes || echo no`)
+		pkg, err := sshCommand(deps, h, distro.AuditdInstalled)
+		if err != nil {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				IP:           ip,
+				Pass:         false,
+				Reason:       fmt.Sprintf("SSH command failed: %v", err),
+			})
+			continue
+		}
+
+		enabled, _ := sshCommand(deps, h, distro.AuditdEnabled)
+		active, _ := sshCommand(deps, h, distro.AuditdActive)
 
 		reasons := []string{}
 		if pkg != "yes" {
-			reasons = append(reasons, "auditd package not installed")
+			reasons = append(reasons, distro.AuditdPackage+" package not installed")
 		}
 		if enabled != "yes" {
 			reasons = append(reasons, "auditd service not enabled")
@@ -71,12 +86,14 @@ func (Droplet216AuditdEnabled) Run(ctx context.Context, deps cisctl.Deps) (cisct
 
 		pass := len(reasons) == 0
 		f := cisctl.Finding{
-			ResourceType: "droplet",
-			ResourceID:   fmt.Sprintf("%d", d.ID),
-			ResourceName: d.Name,
+			ResourceType: h.ResourceType,
+			ResourceID:   h.ResourceID,
+			ResourceName: h.Name,
 			IP:           ip,
 			Pass:         pass,
 			Evidence: map[string]string{
+				"os":              osr.String(),
+				"os_family":       distro.Family,
 				"pkg_installed":   pkg,
 				"service_enabled": enabled,
 				"service_active":  active,
@@ -85,10 +102,10 @@ func (Droplet216AuditdEnabled) Run(ctx context.Context, deps cisctl.Deps) (cisct
 		if !pass {
 			f.Reason = strings.Join(reasons, "; ")
 			if deps.Log != nil {
-				deps.Log.Errorf("%s: %s", d.Name, f.Reason)
+				deps.Log.WithFinding(f).Error("auditd check failed", "reason", f.Reason)
 			}
 		} else if deps.Log != nil {
-			deps.Log.Infof("%s: auditd enabled and running", d.Name)
+			deps.Log.WithFinding(f).Info("auditd enabled and running")
 		}
 
 		out.Findings = append(out.Findings, f)
@@ -97,3 +114,17 @@ func (Droplet216AuditdEnabled) Run(ctx context.Context, deps cisctl.Deps) (cisct
 	return out, nil
 }
 
+func (Droplet216AuditdEnabled) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet", "host"},
+		APICalls:      []string{apiListDroplets},
+		Commands: distroCommands(func(d hostDistro) []string {
+			return []string{d.AuditdInstalled, d.AuditdEnabled, d.AuditdActive}
+		}),
+		EvidenceKeys: []string{"os", "os_family", "pkg_installed", "service_enabled", "service_active"},
+	}
+}
+
+func (Droplet216AuditdEnabled) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	return planHostRemediation(deps, result, "auditd", auditdFix), nil
+}
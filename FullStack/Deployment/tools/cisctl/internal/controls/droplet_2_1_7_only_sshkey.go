@@ -0,0 +1,129 @@
+package controls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cisctl/internal/cisctl"
+)
+
+type Droplet217OnlySSHKey struct{}
+
+func (Droplet217OnlySSHKey) ID() string    { return "2.1.7" }
+func (Droplet217OnlySSHKey) Title() string { return "Ensure Only SSH Key Authentication is Allowed" }
+
+func (Droplet217OnlySSHKey) HostControl() {}
+
+// sshdSettingsCmd prefers the effective config from sshd -T and falls back to
+// grepping sshd_config when sshd cannot be run.
+const sshdSettingsCmd = `out=$( (sudo -n sshd -T 2>/dev/null || sshd -T 2>/dev/null) | grep -Ei '^(passwordauthentication|permitrootlogin) ' ); ` +
+	`[ -n "$out" ] || out=$(grep -Ei '^[[:space:]]*(PasswordAuthentication|PermitRootLogin)[[:space:]]+' /etc/ssh/sshd_config 2>/dev/null); ` +
+	`printf '%s\n' "$out"`
+
+func (Droplet217OnlySSHKey) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
+	var out cisctl.ControlOutcome
+
+	hosts, err := deps.Hosts(ctx)
+	if err != nil {
+		return out, err
+	}
+	if len(hosts) == 0 {
+		out.Findings = append(out.Findings, noHostsFinding(deps))
+		return out, nil
+	}
+
+	for _, h := range hosts {
+		ip := h.Address
+		if strings.TrimSpace(ip) == "" {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				Pass:         false,
+				Reason:       "No public IPv4 address",
+			})
+			continue
+		}
+
+		settings, err := sshCommand(deps, h, sshdSettingsCmd)
+		if err != nil {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				IP:           ip,
+				Pass:         false,
+				Reason:       fmt.Sprintf("SSH unreachable: %v", err),
+			})
+			continue
+		}
+
+		values := parseSSHDSettings(settings)
+		passwordAuth := values["passwordauthentication"]
+		rootLogin := values["permitrootlogin"]
+
+		reasons := []string{}
+		if passwordAuth != "no" {
+			reasons = append(reasons, "PasswordAuthentication is not 'no'")
+		}
+		if rootLogin != "no" {
+			reasons = append(reasons, "PermitRootLogin is not 'no'")
+		}
+
+		pass := len(reasons) == 0
+		f := cisctl.Finding{
+			ResourceType: h.ResourceType,
+			ResourceID:   h.ResourceID,
+			ResourceName: h.Name,
+			IP:           ip,
+			Pass:         pass,
+			Evidence: map[string]string{
+				"password_authentication": passwordAuth,
+				"permit_root_login":       rootLogin,
+			},
+		}
+		if !pass {
+			f.Reason = strings.Join(reasons, "; ")
+			if deps.Log != nil {
+				deps.Log.WithFinding(f).Error("SSH key-only check failed", "reason", f.Reason)
+			}
+		} else if deps.Log != nil {
+			deps.Log.WithFinding(f).Info("password auth and root login disabled")
+		}
+
+		out.Findings = append(out.Findings, f)
+	}
+
+	return out, nil
+}
+
+func (Droplet217OnlySSHKey) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet", "host"},
+		APICalls:      []string{apiListDroplets},
+		Commands:      []string{sshdSettingsCmd},
+		EvidenceKeys:  []string{"password_authentication", "permit_root_login"},
+	}
+}
+
+func (Droplet217OnlySSHKey) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	return planHostRemediation(deps, result, "sshd", anyDistroFix("set PasswordAuthentication no and PermitRootLogin no, validate with sshd -t, reload sshd", sshKeyOnlyPlan())), nil
+}
+
+// parseSSHDSettings maps lower-cased option names to lower-cased values. The
+// first occurrence wins, matching sshd's own precedence.
+func parseSSHDSettings(out string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		if _, seen := values[key]; !seen {
+			values[key] = strings.ToLower(fields[1])
+		}
+	}
+	return values
+}
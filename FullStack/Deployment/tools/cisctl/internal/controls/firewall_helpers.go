@@ -1,6 +1,9 @@
 package controls
 
 import (
+	"context"
+	"fmt"
+
 	"cisctl/internal/cisctl"
 
 	"github.com/digitalocean/godo"
@@ -26,7 +29,61 @@ func dropletInAnyFirewall(dropletID int, firewalls []godo.Firewall) bool {
 	return false
 }
 
-func dropletFirewallCovered(dropletID int, envTag string, firewalls []godo.Firewall) bool {
-	return firewallCoversTag(firewalls, envTag) || dropletInAnyFirewall(dropletID, firewalls)
+// dropletFirewallCovered reports whether a firewall targets d directly or
+// through one of its tags.
+func dropletFirewallCovered(d godo.Droplet, firewalls []godo.Firewall) bool {
+	for _, tag := range d.Tags {
+		if firewallCoversTag(firewalls, tag) {
+			return true
+		}
+	}
+	return dropletInAnyFirewall(d.ID, firewalls)
 }
 
+// planFirewallCoverage proposes adding the uncovered droplets of result to the
+// firewall named by Config.RemediateFirewall, through the env tag when it
+// alone defines the scope so droplets created later are covered too. Other
+// scopes add the droplets one by one.
+func planFirewallCoverage(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	var uncovered []int
+	findings, ids := failedDroplets(result)
+	for i, f := range findings {
+		if f.Evidence["firewall_covered"] == "no" {
+			uncovered = append(uncovered, ids[i])
+		}
+	}
+	if len(uncovered) == 0 {
+		return nil, nil
+	}
+
+	if deps.Config.RemediateFirewall == "" {
+		return []cisctl.RemediationStep{{
+			Key:      "firewall",
+			Resource: fmt.Sprintf("%d droplet(s)", len(uncovered)),
+			Action:   "set REMEDIATE_FIREWALL or --firewall to choose a firewall, or re-apply Terraform module.firewall",
+		}}, nil
+	}
+	fw, err := deps.DO.FindFirewall(ctx, deps.Config.RemediateFirewall)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag := deps.Config.EnvTag; tag != "" && deps.Config.Scope.EnvTagOnly() {
+		return []cisctl.RemediationStep{{
+			Key:      "firewall:" + fw.ID + ":tag:" + tag,
+			Resource: "firewall " + fw.Name,
+			Action:   fmt.Sprintf("add tag %s (covers %d droplet(s))", tag, len(uncovered)),
+			Apply: func(ctx context.Context) error {
+				return deps.DO.AddFirewallTags(ctx, fw.ID, tag)
+			},
+		}}, nil
+	}
+	return []cisctl.RemediationStep{{
+		Key:      fmt.Sprintf("firewall:%s:droplets:%v", fw.ID, uncovered),
+		Resource: "firewall " + fw.Name,
+		Action:   fmt.Sprintf("add droplets %v", uncovered),
+		Apply: func(ctx context.Context) error {
+			return deps.DO.AddFirewallDroplets(ctx, fw.ID, uncovered...)
+		},
+	}}, nil
+}
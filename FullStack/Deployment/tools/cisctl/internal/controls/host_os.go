@@ -0,0 +1,185 @@
+package controls
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cisctl/internal/cisctl"
+)
+
+// cmdOSRelease prints the host's os-release file; it prints nothing on hosts
+// without one.
+const cmdOSRelease = `cat /etc/os-release 2>/dev/null || cat /usr/lib/os-release 2>/dev/null || true`
+
+// hostOS is a host's distribution as read from os-release.
+type hostOS struct {
+	ID        string
+	IDLike    []string
+	VersionID string
+}
+
+func parseOSRelease(out string) hostOS {
+	var o hostOS
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			o.ID = strings.ToLower(value)
+		case "ID_LIKE":
+			o.IDLike = strings.Fields(strings.ToLower(value))
+		case "VERSION_ID":
+			o.VersionID = value
+		}
+	}
+	return o
+}
+
+// String renders the distribution as evidence, e.g. "ubuntu 22.04".
+func (o hostOS) String() string {
+	if o.ID == "" {
+		return "unknown"
+	}
+	return strings.TrimSpace(o.ID + " " + o.VersionID)
+}
+
+func (o hostOS) is(ids ...string) bool {
+	return slices.Contains(ids, o.ID) || slices.ContainsFunc(o.IDLike, func(id string) bool { return slices.Contains(ids, id) })
+}
+
+// hostDistro holds the host check commands and fixes of one distribution
+// family. Each command prints yes or no.
+type hostDistro struct {
+	Family string
+
+	// Automatic updates (2.1.4, 2.1.5): the package, whether it applies
+	// updates, whether it refreshes package metadata, and its timers. The
+	// labels name each setting in reasons.
+	UpdatesPackage       string
+	UpdatesInstalled     string
+	UpdatesApply         string
+	UpdatesApplyLabel    string
+	UpdatesRefresh       string
+	UpdatesRefreshLabel  string
+	UpdatesTimersEnabled string
+	UpdatesTimersActive  string
+	UpdatesTimersLabel   string
+	UpdatesAction        string
+	UpdatesPlan          cisctl.HostPlan
+
+	// auditd (2.1.6).
+	AuditdPackage   string
+	AuditdInstalled string
+	AuditdEnabled   string
+	AuditdActive    string
+	AuditdAction    string
+	AuditdPlan      cisctl.HostPlan
+}
+
+var debianDistro = hostDistro{
+	Family:               "debian",
+	UpdatesPackage:       "unattended-upgrades",
+	UpdatesInstalled:     cmdUnattendedInstalled,
+	UpdatesApply:         cmdUnattendedUpgradeEnabled,
+	UpdatesApplyLabel:    "Unattended-Upgrade",
+	UpdatesRefresh:       cmdUpdateListsEnabled,
+	UpdatesRefreshLabel:  "Update-Package-Lists",
+	UpdatesTimersEnabled: cmdAptTimersEnabled,
+	UpdatesTimersActive:  cmdAptTimersActive,
+	UpdatesTimersLabel:   "apt-daily timers",
+	UpdatesAction:        "install unattended-upgrades, write 20auto-upgrades, enable apt-daily timers",
+	UpdatesPlan:          unattendedUpgradesPlan(),
+	AuditdPackage:        "auditd",
+	AuditdInstalled:      cmdAuditdInstalled,
+	AuditdEnabled:        cmdAuditdEnabled,
+	AuditdActive:         cmdAuditdActive,
+	AuditdAction:         "install auditd, enable and start the service",
+	AuditdPlan:           auditdPlan(),
+}
+
+// rhelDistro covers RHEL, Rocky, Alma, CentOS Stream and Fedora with dnf 4's
+// dnf-automatic. The audit package ships auditd.
+var rhelDistro = hostDistro{
+	Family:               "rhel",
+	UpdatesPackage:       "dnf-automatic",
+	UpdatesInstalled:     cmdDnfAutomaticInstalled,
+	UpdatesApply:         cmdDnfApplyUpdates,
+	UpdatesApplyLabel:    "apply_updates",
+	UpdatesRefresh:       cmdDnfDownloadUpdates,
+	UpdatesRefreshLabel:  "download_updates",
+	UpdatesTimersEnabled: cmdDnfTimerEnabled,
+	UpdatesTimersActive:  cmdDnfTimerActive,
+	UpdatesTimersLabel:   "dnf-automatic timer",
+	UpdatesAction:        "install dnf-automatic, set apply_updates = yes, enable dnf-automatic.timer",
+	UpdatesPlan:          dnfAutomaticPlan(),
+	AuditdPackage:        "audit",
+	AuditdInstalled:      cmdRPMAuditInstalled,
+	AuditdEnabled:        cmdAuditdEnabled,
+	AuditdActive:         cmdAuditdActive,
+	AuditdAction:         "install audit, enable and start auditd",
+	AuditdPlan:           rpmAuditdPlan(),
+}
+
+const (
+	dnfAutomaticConfPath = "/etc/dnf/automatic.conf"
+
+	cmdDnfAutomaticInstalled = `rpm -q dnf-automatic >/dev/null 2>&1 && echo yes || echo no`
+	cmdDnfApplyUpdates       = `grep -Eqi '^\s*apply_updates\s*=\s*(yes|true|1|on)\s*$' /etc/dnf/automatic.conf 2>/dev/null && echo yes || echo no`
+	cmdDnfDownloadUpdates    = `grep -Eqi '^\s*download_updates\s*=\s*(yes|true|1|on)\s*$' /etc/dnf/automatic.conf 2>/dev/null && echo yes || echo no`
+	cmdDnfTimerEnabled       = `systemctl is-enabled dnf-automatic.timer >/dev/null 2>&1 && echo yes || echo no`
+	cmdDnfTimerActive        = `systemctl is-active dnf-automatic.timer >/dev/null 2>&1 && echo yes || echo no`
+	cmdRPMAuditInstalled     = `rpm -q audit >/dev/null 2>&1 && echo yes || echo no`
+)
+
+// distroFor returns the strategy for o, or false for unsupported
+// distributions.
+func distroFor(o hostOS) (hostDistro, bool) {
+	switch {
+	case o.is("debian", "ubuntu"):
+		return debianDistro, true
+	case o.is("rhel", "centos", "fedora", "rocky", "almalinux"):
+		return rhelDistro, true
+	default:
+		return hostDistro{}, false
+	}
+}
+
+// detectDistro reads the distribution of h.
+func detectDistro(deps cisctl.Deps, h cisctl.Host) (hostOS, hostDistro, bool, error) {
+	out, err := sshCommand(deps, h, cmdOSRelease)
+	if err != nil {
+		return hostOS{}, hostDistro{}, false, err
+	}
+	o := parseOSRelease(out)
+	d, ok := distroFor(o)
+	return o, d, ok, nil
+}
+
+// notApplicableFinding reports a host whose distribution the control has no
+// checks for.
+func notApplicableFinding(h cisctl.Host, o hostOS) cisctl.Finding {
+	return cisctl.Finding{
+		ResourceType:  h.ResourceType,
+		ResourceID:    h.ResourceID,
+		ResourceName:  h.Name,
+		IP:            h.Address,
+		Pass:          true,
+		NotApplicable: true,
+		Reason:        fmt.Sprintf("unsupported distribution %s", o),
+		Evidence:      map[string]string{"os": o.String()},
+	}
+}
+
+// distroCommands lists the commands of every supported distribution for
+// `cisctl describe`.
+func distroCommands(commands func(d hostDistro) []string) []string {
+	out := []string{cmdOSRelease}
+	for _, d := range []hostDistro{debianDistro, rhelDistro} {
+		out = append(out, commands(d)...)
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package controls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cisctl/internal/cisctl"
+)
+
+const (
+	autoUpgradesPath = "/etc/apt/apt.conf.d/20auto-upgrades"
+	sshdConfigPath   = "/etc/ssh/sshd_config"
+	sshdDropInPath   = "/etc/ssh/sshd_config.d/00-cisctl-hardening.conf"
+)
+
+// hostFix returns the action and plan that fix the host behind a finding; ok
+// is false when its distribution is not known.
+type hostFix func(f cisctl.Finding) (action string, plan cisctl.HostPlan, ok bool)
+
+// anyDistroFix applies the same plan on every distribution.
+func anyDistroFix(action string, plan cisctl.HostPlan) hostFix {
+	return func(cisctl.Finding) (string, cisctl.HostPlan, bool) { return action, plan, true }
+}
+
+// distroFix picks the plan for the os_family evidence of the finding.
+func distroFix(pick func(d hostDistro) (string, cisctl.HostPlan)) hostFix {
+	return func(f cisctl.Finding) (string, cisctl.HostPlan, bool) {
+		for _, d := range []hostDistro{debianDistro, rhelDistro} {
+			if d.Family == f.Evidence["os_family"] {
+				action, plan := pick(d)
+				return action, plan, true
+			}
+		}
+		return "", cisctl.HostPlan{}, false
+	}
+}
+
+var (
+	updatesFix = distroFix(func(d hostDistro) (string, cisctl.HostPlan) { return d.UpdatesAction, d.UpdatesPlan })
+	auditdFix  = distroFix(func(d hostDistro) (string, cisctl.HostPlan) { return d.AuditdAction, d.AuditdPlan })
+)
+
+// planHostRemediation returns one step per failed droplet or inventory host
+// of result that applies the fix over the run's executor. Hosts that could
+// not be checked (no evidence was collected) or whose distribution has no
+// fix get a manual step instead.
+func planHostRemediation(deps cisctl.Deps, result cisctl.ControlResult, key string, fix hostFix) []cisctl.RemediationStep {
+	var steps []cisctl.RemediationStep
+	for _, f := range failedHosts(result) {
+		if f.IP == "" || f.Evidence == nil {
+			steps = append(steps, cisctl.RemediationStep{
+				Key:      "ssh:" + f.ResourceID,
+				Resource: hostLabel(f),
+				Action:   fmt.Sprintf("host could not be checked (%s); restore SSH access and re-run", f.Reason),
+			})
+			continue
+		}
+		action, plan, ok := fix(f)
+		if !ok {
+			steps = append(steps, cisctl.RemediationStep{
+				Key:      key + ":" + f.ResourceID,
+				Resource: hostLabel(f),
+				Action:   fmt.Sprintf("no automatic fix for distribution %s; fix manually", f.Evidence["os"]),
+			})
+			continue
+		}
+		// The runner finds the host's SSH settings by its ID.
+		host := cisctl.Host{
+			Name:         f.ResourceName,
+			Address:      f.IP,
+			ResourceType: f.ResourceType,
+			ResourceID:   f.ResourceID,
+		}
+		log := deps.Log.WithFinding(f)
+		steps = append(steps, cisctl.RemediationStep{
+			Key:      key + ":" + f.ResourceID,
+			Resource: hostLabel(f),
+			Action:   action,
+			Apply: func(ctx context.Context) error {
+				return plan.Apply(ctx, deps.Exec, host, log)
+			},
+		})
+	}
+	return steps
+}
+
+// unattendedUpgradesPlan covers both 2.1.4 and 2.1.5 so the two controls
+// propose the same step and it is applied once.
+func unattendedUpgradesPlan() cisctl.HostPlan {
+	return cisctl.HostPlan{
+		Backup: []string{autoUpgradesPath},
+		Ops: []cisctl.HostOp{
+			{Desc: "install unattended-upgrades", Cmd: aptInstall("unattended-upgrades")},
+			{Desc: "write " + autoUpgradesPath, Cmd: writeLines(autoUpgradesPath,
+				`APT::Periodic::Update-Package-Lists "1";`,
+				`APT::Periodic::Unattended-Upgrade "1";`,
+			)},
+			{Desc: "enable apt-daily timers", Cmd: "systemctl enable --now apt-daily.timer apt-daily-upgrade.timer"},
+		},
+	}
+}
+
+// dnfAutomaticPlan covers both 2.1.4 and 2.1.5 on the RHEL family.
+func dnfAutomaticPlan() cisctl.HostPlan {
+	return cisctl.HostPlan{
+		Backup: []string{dnfAutomaticConfPath},
+		Ops: []cisctl.HostOp{
+			{Desc: "install dnf-automatic", Cmd: dnfInstall("dnf-automatic")},
+			{Desc: "set download_updates = yes", Cmd: setINIOption(dnfAutomaticConfPath, "download_updates", "yes")},
+			{Desc: "set apply_updates = yes", Cmd: setINIOption(dnfAutomaticConfPath, "apply_updates", "yes")},
+			{Desc: "enable dnf-automatic timer", Cmd: "systemctl enable --now dnf-automatic.timer"},
+		},
+	}
+}
+
+func rpmAuditdPlan() cisctl.HostPlan {
+	return cisctl.HostPlan{
+		Ops: []cisctl.HostOp{
+			{Desc: "install audit", Cmd: dnfInstall("audit")},
+			{Desc: "enable and start auditd", Cmd: "systemctl enable --now auditd"},
+		},
+	}
+}
+
+func auditdPlan() cisctl.HostPlan {
+	return cisctl.HostPlan{
+		Ops: []cisctl.HostOp{
+			{Desc: "install auditd", Cmd: aptInstall("auditd")},
+			{Desc: "enable and start auditd", Cmd: "systemctl enable --now auditd"},
+		},
+	}
+}
+
+// sshKeyOnlyPlan edits sshd_config and, where sshd_config.d exists, writes a
+// drop-in that sorts first so cloud-init's PasswordAuthentication yes cannot
+// override it. sshd is only reloaded once sshd -t accepts the result.
+func sshKeyOnlyPlan() cisctl.HostPlan {
+	return cisctl.HostPlan{
+		Backup: []string{sshdConfigPath, sshdDropInPath},
+		Ops: []cisctl.HostOp{
+			{Desc: "check SSH user is not root (PermitRootLogin no would lock it out)", Cmd: `test "$(id -un)" != root`, AsUser: true},
+			{Desc: "set PasswordAuthentication no", Cmd: setSSHDOption("PasswordAuthentication", "no")},
+			{Desc: "set PermitRootLogin no", Cmd: setSSHDOption("PermitRootLogin", "no")},
+			{Desc: "write " + sshdDropInPath, Cmd: "if [ -d /etc/ssh/sshd_config.d ]; then " +
+				writeLines(sshdDropInPath, "PasswordAuthentication no", "PermitRootLogin no") + "; fi"},
+			{Desc: "validate sshd config", Cmd: "sshd -t"},
+			{Desc: "reload sshd", Cmd: "systemctl reload ssh || systemctl reload sshd"},
+		},
+		Rollback: "sshd -t && (systemctl reload ssh || systemctl reload sshd)",
+	}
+}
+
+func aptInstall(pkgs ...string) string {
+	return "export DEBIAN_FRONTEND=noninteractive; apt-get update -qq && apt-get install -y -qq " + strings.Join(pkgs, " ")
+}
+
+func dnfInstall(pkgs ...string) string {
+	return "dnf install -y -q " + strings.Join(pkgs, " ")
+}
+
+// setINIOption sets key = value where the key already appears, commented out
+// or not; dnf-automatic's shipped config lists every option.
+func setINIOption(path string, key string, value string) string {
+	return fmt.Sprintf(`grep -Eq '^[#[:space:]]*%[1]s[[:space:]]*=' %[3]s && sed -i -E 's/^[#[:space:]]*%[1]s[[:space:]]*=.*/%[1]s = %[2]s/' %[3]s`,
+		key, value, path)
+}
+
+func writeLines(path string, lines ...string) string {
+	quoted := make([]string, len(lines))
+	for i, l := range lines {
+		quoted[i] = shellQuote(l)
+	}
+	return fmt.Sprintf("printf '%%s\\n' %s > %s", strings.Join(quoted, " "), path)
+}
+
+func setSSHDOption(key string, value string) string {
+	return fmt.Sprintf(`if grep -Eq '^[#[:space:]]*%[1]s[[:space:]]' %[3]s; then sed -i -E 's/^[#[:space:]]*%[1]s[[:space:]].*/%[1]s %[2]s/' %[3]s; else echo '%[1]s %[2]s' >> %[3]s; fi`,
+		key, value, sshdConfigPath)
+}
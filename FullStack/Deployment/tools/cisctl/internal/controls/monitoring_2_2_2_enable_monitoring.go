@@ -0,0 +1,209 @@
+package controls
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"cisctl/internal/cisctl"
+
+	"github.com/digitalocean/godo"
+)
+
+type Monitoring222EnableMonitoring struct{}
+
+func (Monitoring222EnableMonitoring) ID() string    { return "2.2.2" }
+func (Monitoring222EnableMonitoring) Title() string { return "Ensure Monitoring is Enabled and a CPU Alert Policy Exists" }
+
+func (Monitoring222EnableMonitoring) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
+	var out cisctl.ControlOutcome
+
+	droplets, err := deps.Droplets(ctx)
+	if err != nil {
+		return out, err
+	}
+	if len(droplets) == 0 {
+		out.Findings = append(out.Findings, cisctl.Finding{
+			ResourceType: "droplet",
+			Pass:         false,
+			Reason:       fmt.Sprintf("No droplets found for %s", deps.Config.ScopeString()),
+		})
+		return out, nil
+	}
+
+	for _, d := range droplets {
+		hasMonitoring := cisctl.HasFeature(d, "monitoring")
+		f := cisctl.Finding{
+			ResourceType: "droplet",
+			ResourceID:   fmt.Sprintf("%d", d.ID),
+			ResourceName: d.Name,
+			IP:           cisctl.DropletPublicIPv4(d),
+			Pass:         hasMonitoring,
+		}
+		if !hasMonitoring {
+			f.Reason = "Monitoring not enabled on droplet"
+			if deps.Log != nil {
+				deps.Log.WithFinding(f).Error("monitoring disabled")
+			}
+		} else if deps.Log != nil {
+			deps.Log.WithFinding(f).Info("monitoring enabled")
+		}
+		out.Findings = append(out.Findings, f)
+	}
+
+	policies, err := deps.DO.ListAlertPolicies(ctx)
+	if err != nil {
+		return out, err
+	}
+	cpuAlerts := cpuAlertPolicies(policies, droplets, deps.Config.Scope.Tags(deps.Config.EnvTag))
+	f := cisctl.Finding{
+		ResourceType: "alert_policy",
+		ResourceName: deps.Config.EnvTag,
+		Pass:         len(cpuAlerts) > 0,
+		Evidence: map[string]string{
+			"cpu_alert_policies": strconv.Itoa(len(cpuAlerts)),
+		},
+	}
+	if !f.Pass {
+		f.Reason = "No enabled CPU alert policy found"
+		if deps.Log != nil {
+			deps.Log.WithFinding(f).Error("no enabled CPU alert policy attached to droplets or tag")
+		}
+	} else if deps.Log != nil {
+		deps.Log.WithFinding(f).Info("CPU alert policy found", "count", len(cpuAlerts))
+	}
+	out.Findings = append(out.Findings, f)
+
+	return out, nil
+}
+
+// cpuAlertPolicies returns the enabled CPU policies that target one of the
+// scope's tags or at least one of the droplets directly.
+func cpuAlertPolicies(policies []godo.AlertPolicy, droplets []godo.Droplet, tags []string) []godo.AlertPolicy {
+	var out []godo.AlertPolicy
+	for _, p := range policies {
+		if p.Type != godo.DropletCPUUtilizationPercent || !p.Enabled {
+			continue
+		}
+		covered := slices.ContainsFunc(tags, func(t string) bool { return cisctl.HasString(p.Tags, t) })
+		for _, d := range droplets {
+			if cisctl.HasString(p.Entities, strconv.Itoa(d.ID)) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (Monitoring222EnableMonitoring) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	droplets := plan.ResourcesOfType(tfDroplet)
+	if len(droplets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("droplet", tfDroplet))
+		return out
+	}
+	for _, d := range droplets {
+		f := planFinding("droplet", d)
+		monitoring, known := d.Bool("monitoring")
+		f.Pass = monitoring
+		switch {
+		case !known:
+			f.Reason = "Monitoring not known until apply"
+		case !monitoring:
+			f.Reason = "Monitoring not enabled on droplet"
+		}
+		out.Findings = append(out.Findings, f)
+	}
+
+	cpuAlerts := 0
+	for _, p := range plan.ResourcesOfType(tfMonitorAlert) {
+		enabled, _ := p.Bool("enabled")
+		if p.String("type") != godo.DropletCPUUtilizationPercent || !enabled {
+			continue
+		}
+		if slices.ContainsFunc(droplets, func(d cisctl.TerraformResource) bool { return planTargets(plan, p, "entities", d) }) {
+			cpuAlerts++
+		}
+	}
+	f := cisctl.Finding{
+		ResourceType: "alert_policy",
+		Pass:         cpuAlerts > 0,
+		Evidence: map[string]string{
+			"cpu_alert_policies": strconv.Itoa(cpuAlerts),
+		},
+	}
+	if !f.Pass {
+		f.Reason = "No enabled CPU alert policy in plan targets the droplets"
+	}
+	out.Findings = append(out.Findings, f)
+	return out
+}
+
+func (Monitoring222EnableMonitoring) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet", "alert_policy"},
+		APICalls:      []string{apiListDroplets, apiListAlertPolicies},
+		EvidenceKeys:  []string{"cpu_alert_policies"},
+	}
+}
+
+func (Monitoring222EnableMonitoring) PlanRemediation(ctx context.Context, deps cisctl.Deps, result cisctl.ControlResult) ([]cisctl.RemediationStep, error) {
+	var steps []cisctl.RemediationStep
+	findings, _ := failedDroplets(result)
+	for _, f := range findings {
+		steps = append(steps, cisctl.RemediationStep{
+			Key:      "monitoring:" + f.ResourceID,
+			Resource: dropletLabel(f),
+			Action:   "monitoring cannot be enabled on an existing droplet through the API; install the metrics agent (do-agent) on the host",
+		})
+	}
+
+	for _, f := range result.Findings {
+		if !f.Failing() || f.ResourceType != "alert_policy" {
+			continue
+		}
+		tag := deps.Config.EnvTag
+		if len(deps.Config.AlertEmails) == 0 {
+			steps = append(steps, cisctl.RemediationStep{
+				Key:      "alert:cpu:" + tag,
+				Resource: "alert policy for " + tag,
+				Action:   "set ALERT_EMAILS_JSON so a CPU alert policy can be created, or re-apply Terraform digitalocean_monitor_alert",
+			})
+			continue
+		}
+		emails := deps.Config.AlertEmails
+		// The threshold and window can be tuned per profile:
+		// control_params: {"2.2.2": {cpu_threshold: "90", cpu_window: "10m"}}
+		raw := deps.Config.ControlParam("2.2.2", "cpu_threshold", "80")
+		threshold, err := strconv.ParseFloat(raw, 32)
+		if err != nil || threshold <= 0 || threshold > 100 {
+			return nil, fmt.Errorf("invalid cpu_threshold %q", raw)
+		}
+		window := deps.Config.ControlParam("2.2.2", "cpu_window", "5m")
+		steps = append(steps, cisctl.RemediationStep{
+			Key:      "alert:cpu:" + tag,
+			Resource: "alert policy for " + tag,
+			Action:   fmt.Sprintf("create CPU > %g%% (%s) alert policy notifying %v", threshold, window, emails),
+			Apply: func(ctx context.Context) error {
+				enabled := true
+				_, err := deps.DO.CreateAlertPolicy(ctx, &godo.AlertPolicyCreateRequest{
+					Type:        godo.DropletCPUUtilizationPercent,
+					Description: fmt.Sprintf("CPU usage > %g%% (%s)", threshold, tag),
+					Compare:     godo.GreaterThan,
+					Value:       float32(threshold),
+					Window:      window,
+					Tags:        []string{tag},
+					Alerts:      godo.Alerts{Email: emails},
+					Enabled:     &enabled,
+				})
+				return err
+			},
+		})
+	}
+	return steps, nil
+}
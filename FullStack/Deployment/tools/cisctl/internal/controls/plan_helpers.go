@@ -0,0 +1,89 @@
+package controls
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cisctl/internal/cisctl"
+)
+
+// Terraform resource types of the plan controls.
+const (
+	tfDroplet      = "digitalocean_droplet"
+	tfFirewall     = "digitalocean_firewall"
+	tfVPC          = "digitalocean_vpc"
+	tfSpacesBucket = "digitalocean_spaces_bucket"
+	tfCDN          = "digitalocean_cdn"
+	tfMonitorAlert = "digitalocean_monitor_alert"
+)
+
+// planFinding starts the finding of a planned resource. Planned resources may
+// not exist yet, so they are identified by their Terraform address.
+func planFinding(resourceType string, r cisctl.TerraformResource) cisctl.Finding {
+	return cisctl.Finding{
+		ResourceType: resourceType,
+		ResourceID:   r.Address,
+		ResourceName: r.String("name"),
+		Evidence:     map[string]string{"planned_action": r.Action},
+	}
+}
+
+// noPlanResourcesFinding reports a plan without resources of tfType, which
+// the control then does not apply to.
+func noPlanResourcesFinding(resourceType string, tfType string) cisctl.Finding {
+	return cisctl.Finding{
+		ResourceType:  resourceType,
+		Pass:          true,
+		NotApplicable: true,
+		Reason:        fmt.Sprintf("No %s resources in plan", tfType),
+	}
+}
+
+// planTargets reports whether a resource of the plan that targets droplets
+// through ids and tags attributes, such as a firewall or an alert policy,
+// targets d. IDs of droplets that do not exist yet are unknown in the plan, so
+// references to d's configuration are followed instead.
+func planTargets(plan *cisctl.TerraformPlan, target cisctl.TerraformResource, idsKey string, d cisctl.TerraformResource) bool {
+	tags := target.Strings("tags")
+	if slices.ContainsFunc(d.Strings("tags"), func(t string) bool { return slices.Contains(tags, t) }) {
+		return true
+	}
+	if id := d.String("id"); id != "" && slices.Contains(target.Strings(idsKey), id) {
+		return true
+	}
+	return slices.Contains(plan.References(target, idsKey), d.ConfigAddress())
+}
+
+func planFirewallCovered(plan *cisctl.TerraformPlan, d cisctl.TerraformResource) bool {
+	for _, fw := range plan.ResourcesOfType(tfFirewall) {
+		if planTargets(plan, fw, "droplet_ids", d) {
+			return true
+		}
+	}
+	return false
+}
+
+// planVPC returns the VPC a planned droplet joins: its UUID when known, else
+// the VPC resource it references. ok is false when none is set and the
+// droplet would land in the region's default VPC.
+func planVPC(plan *cisctl.TerraformPlan, d cisctl.TerraformResource) (string, bool) {
+	if v := d.String("vpc_uuid"); v != "" {
+		return v, true
+	}
+	for _, addr := range plan.References(d, "vpc_uuid") {
+		if planAddressType(addr) == tfVPC {
+			return "known after apply: " + addr, true
+		}
+	}
+	return "", false
+}
+
+// planAddressType returns the resource type of a configuration address.
+func planAddressType(addr string) string {
+	parts := strings.Split(addr, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
@@ -0,0 +1,57 @@
+package controls
+
+import (
+	"fmt"
+	"strconv"
+
+	"cisctl/internal/cisctl"
+)
+
+// failedDroplets returns the failed findings of result that name a droplet,
+// together with the parsed droplet IDs.
+func failedDroplets(result cisctl.ControlResult) ([]cisctl.Finding, []int) {
+	var findings []cisctl.Finding
+	var ids []int
+	for _, f := range result.Findings {
+		if !f.Failing() || f.ResourceType != "droplet" {
+			continue
+		}
+		id, err := strconv.Atoi(f.ResourceID)
+		if err != nil {
+			continue
+		}
+		findings = append(findings, f)
+		ids = append(ids, id)
+	}
+	return findings, ids
+}
+
+// failedHosts returns the failed findings of result that name a droplet or an
+// inventory host.
+func failedHosts(result cisctl.ControlResult) []cisctl.Finding {
+	var findings []cisctl.Finding
+	for _, f := range result.Findings {
+		if f.Failing() && f.ResourceID != "" && (f.ResourceType == "droplet" || f.ResourceType == "host") {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func hostLabel(f cisctl.Finding) string {
+	if f.ResourceType == "host" {
+		return "host " + f.ResourceName
+	}
+	return dropletLabel(f)
+}
+
+func dropletLabel(f cisctl.Finding) string {
+	return fmt.Sprintf("droplet %s (%s)", f.ResourceName, f.ResourceID)
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,44 @@
+package controls
+
+import "cisctl/internal/cisctl"
+
+// Spaces232AccessKeys checks that the Spaces keys the DigitalOcean provider
+// uses come from variables or the environment rather than the code. It only
+// runs as a plan check.
+type Spaces232AccessKeys struct{}
+
+func (Spaces232AccessKeys) ID() string    { return "2.3.2" }
+func (Spaces232AccessKeys) Title() string { return "Ensure Spaces Access Keys are not Hardcoded" }
+
+var spacesKeySettings = []string{"spaces_access_id", "spaces_secret_key"}
+
+func (Spaces232AccessKeys) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	f := cisctl.Finding{
+		ResourceType: "provider",
+		ResourceName: "digitalocean",
+		Pass:         true,
+		Evidence:     map[string]string{},
+	}
+	for _, key := range spacesKeySettings {
+		literal := plan.ProviderLiteral("digitalocean", key)
+		f.Evidence[key+"_literal"] = yesNo(literal)
+		if literal {
+			f.Pass = false
+			f.Reason = "Spaces key hardcoded in provider configuration"
+		}
+	}
+	if f.Pass && len(plan.ResourcesOfType(tfSpacesBucket)) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("bucket", tfSpacesBucket))
+		return out
+	}
+	out.Findings = append(out.Findings, f)
+	return out
+}
+
+func (Spaces232AccessKeys) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"provider"},
+		EvidenceKeys:  []string{"spaces_access_id_literal", "spaces_secret_key_literal"},
+	}
+}
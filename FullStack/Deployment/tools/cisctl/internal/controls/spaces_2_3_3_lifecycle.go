@@ -0,0 +1,60 @@
+package controls
+
+import (
+	"strconv"
+
+	"cisctl/internal/cisctl"
+)
+
+// Spaces233Lifecycle checks that every bucket has an enabled lifecycle rule
+// that expires objects. It only runs as a plan check.
+type Spaces233Lifecycle struct{}
+
+func (Spaces233Lifecycle) ID() string    { return "2.3.3" }
+func (Spaces233Lifecycle) Title() string { return "Ensure Bucket Lifecycle Policy is Configured" }
+
+func (Spaces233Lifecycle) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	buckets := plan.ResourcesOfType(tfSpacesBucket)
+	if len(buckets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("bucket", tfSpacesBucket))
+		return out
+	}
+	for _, b := range buckets {
+		f := planFinding("bucket", b)
+		days := bucketExpirationDays(b)
+		f.Pass = days > 0
+		if f.Pass {
+			f.Evidence["expiration_days"] = strconv.Itoa(days)
+		} else {
+			f.Reason = "Lifecycle missing enabled rule with expiration days"
+		}
+		out.Findings = append(out.Findings, f)
+	}
+	return out
+}
+
+// bucketExpirationDays returns the expiration days of the first enabled
+// lifecycle rule that sets them, or 0.
+func bucketExpirationDays(b cisctl.TerraformResource) int {
+	for _, rule := range b.Blocks("lifecycle_rule") {
+		if enabled, _ := rule["enabled"].(bool); !enabled {
+			continue
+		}
+		expirations, _ := rule["expiration"].([]any)
+		for _, exp := range expirations {
+			exp, _ := exp.(map[string]any)
+			if days, ok := exp["days"].(float64); ok && days > 0 {
+				return int(days)
+			}
+		}
+	}
+	return 0
+}
+
+func (Spaces233Lifecycle) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"bucket"},
+		EvidenceKeys:  []string{"planned_action", "expiration_days"},
+	}
+}
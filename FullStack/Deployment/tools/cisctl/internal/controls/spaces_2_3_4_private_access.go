@@ -0,0 +1,44 @@
+package controls
+
+import "cisctl/internal/cisctl"
+
+// Spaces234PrivateAccess checks that no bucket grants public read, which
+// also allows anyone to list its objects. It only runs as a plan check.
+type Spaces234PrivateAccess struct{}
+
+func (Spaces234PrivateAccess) ID() string    { return "2.3.4" }
+func (Spaces234PrivateAccess) Title() string { return "Ensure Bucket Listing is Restricted" }
+
+func (Spaces234PrivateAccess) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	buckets := plan.ResourcesOfType(tfSpacesBucket)
+	if len(buckets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("bucket", tfSpacesBucket))
+		return out
+	}
+	for _, b := range buckets {
+		f := planFinding("bucket", b)
+		acl := b.String("acl")
+		if acl == "" && !b.Unknown("acl") {
+			// The provider defaults to private.
+			acl = "private"
+		}
+		f.Evidence["acl"] = acl
+		f.Pass = acl == "private"
+		switch {
+		case acl == "":
+			f.Reason = "ACL not known until apply"
+		case !f.Pass:
+			f.Reason = "Bucket ACL is " + acl
+		}
+		out.Findings = append(out.Findings, f)
+	}
+	return out
+}
+
+func (Spaces234PrivateAccess) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"bucket"},
+		EvidenceKeys:  []string{"planned_action", "acl"},
+	}
+}
@@ -0,0 +1,50 @@
+package controls
+
+import (
+	"slices"
+
+	"cisctl/internal/cisctl"
+)
+
+// Spaces235CDN checks that every bucket is served through a CDN endpoint. It
+// only runs as a plan check.
+type Spaces235CDN struct{}
+
+func (Spaces235CDN) ID() string    { return "2.3.5" }
+func (Spaces235CDN) Title() string { return "Ensure CDN is Enabled for Spaces" }
+
+func (Spaces235CDN) ScanPlan(plan *cisctl.TerraformPlan) cisctl.ControlOutcome {
+	var out cisctl.ControlOutcome
+	buckets := plan.ResourcesOfType(tfSpacesBucket)
+	if len(buckets) == 0 {
+		out.Findings = append(out.Findings, noPlanResourcesFinding("bucket", tfSpacesBucket))
+		return out
+	}
+	cdns := plan.ResourcesOfType(tfCDN)
+	for _, b := range buckets {
+		f := planFinding("bucket", b)
+		f.Pass = slices.ContainsFunc(cdns, func(c cisctl.TerraformResource) bool { return cdnServesBucket(plan, c, b) })
+		f.Evidence["cdn"] = yesNo(f.Pass)
+		if !f.Pass {
+			f.Reason = "No CDN in plan has the bucket as origin"
+		}
+		out.Findings = append(out.Findings, f)
+	}
+	return out
+}
+
+// cdnServesBucket reports whether c's origin is b, by domain when both are
+// known and otherwise by reference.
+func cdnServesBucket(plan *cisctl.TerraformPlan, c cisctl.TerraformResource, b cisctl.TerraformResource) bool {
+	if origin := c.String("origin"); origin != "" && origin == b.String("bucket_domain_name") {
+		return true
+	}
+	return slices.Contains(plan.References(c, "origin"), b.ConfigAddress())
+}
+
+func (Spaces235CDN) Describe() cisctl.ControlDescription {
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"bucket"},
+		EvidenceKeys:  []string{"planned_action", "cdn"},
+	}
+}
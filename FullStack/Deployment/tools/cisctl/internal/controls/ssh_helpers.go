@@ -1,13 +1,48 @@
 package controls
 
 import (
+	"fmt"
 	"strings"
 
 	"cisctl/internal/cisctl"
 )
 
-func sshCommand(deps cisctl.Deps, ip string, cmd string) (string, error) {
-	out, err := deps.SSH.RunCommand(ip, cmd)
+func sshCommand(deps cisctl.Deps, h cisctl.Host, cmd string) (string, error) {
+	out, err := deps.Exec.RunCommand(h, cmd)
 	return strings.TrimSpace(out), err
 }
 
+// shellQuote quotes s as one word for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// noHostsFinding reports an empty host source, naming the droplet scope when
+// the hosts are droplets.
+func noHostsFinding(deps cisctl.Deps) cisctl.Finding {
+	if _, ok := deps.HostSource.(cisctl.DOHosts); ok || deps.HostSource == nil {
+		return cisctl.Finding{
+			ResourceType: "droplet",
+			Pass:         false,
+			Reason:       fmt.Sprintf("No droplets found for %s", deps.Config.ScopeString()),
+		}
+	}
+	return cisctl.Finding{
+		ResourceType: "host",
+		Pass:         false,
+		Reason:       fmt.Sprintf("No hosts found in %s", deps.HostSource),
+	}
+}
+
+// Host check commands. Each prints yes or no so a failed check is not
+// mistaken for an unreachable host.
+const (
+	cmdUnattendedInstalled      = `dpkg -s unattended-upgrades >/dev/null 2>&1 && echo yes || echo no`
+	cmdUnattendedUpgradeEnabled = `grep -Eq 'APT::Periodic::Unattended-Upgrade\s+"1"' /etc/apt/apt.conf.d/20auto-upgrades 2>/dev/null && echo yes || echo no`
+	cmdUpdateListsEnabled       = `grep -Eq 'APT::Periodic::Update-Package-Lists\s+"1"' /etc/apt/apt.conf.d/20auto-upgrades 2>/dev/null && echo yes || echo no`
+	cmdAptTimersEnabled         = `systemctl is-enabled apt-daily.timer apt-daily-upgrade.timer >/dev/null 2>&1 && echo yes || echo no`
+	cmdAptTimersActive          = `systemctl is-active apt-daily.timer apt-daily-upgrade.timer >/dev/null 2>&1 && echo yes || echo no`
+	cmdAuditdInstalled          = `dpkg -s auditd >/dev/null 2>&1 && echo yes || echo no`
+	cmdAuditdEnabled            = `systemctl is-enabled auditd >/dev/null 2>&1 && echo yes || echo no`
+	cmdAuditdActive             = `systemctl is-active auditd >/dev/null 2>&1 && echo yes || echo no`
+)
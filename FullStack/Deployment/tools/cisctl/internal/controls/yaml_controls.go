@@ -0,0 +1,427 @@
+package controls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"cisctl/internal/cisctl"
+)
+
+// yamlControlDef is one control of a controls directory file.
+type yamlControlDef struct {
+	ID       string            `yaml:"id"`
+	Title    string            `yaml:"title"`
+	Metadata map[string]string `yaml:"metadata"`
+	// AppliesTo lists os-release IDs (matched against ID and ID_LIKE) the
+	// control checks; hosts of other distributions are not applicable. Empty
+	// means every host.
+	AppliesTo []string       `yaml:"applies_to"`
+	Checks    []yamlCheckDef `yaml:"checks"`
+	// Pass is "all" (the default) when every check must pass, or "any".
+	Pass string `yaml:"pass"`
+	// Evidence lists the check keys recorded in findings; empty means all.
+	Evidence []string `yaml:"evidence"`
+}
+
+// yamlCheckDef sets exactly one kind of check. Expect false inverts it, e.g.
+// for a package that must not be installed.
+type yamlCheckDef struct {
+	Key              string `yaml:"key"`
+	PackageInstalled string `yaml:"package_installed"`
+	ServiceEnabled   string `yaml:"service_enabled"`
+	ServiceActive    string `yaml:"service_active"`
+	FileContains     *struct {
+		Path  string `yaml:"path"`
+		Regex string `yaml:"regex"`
+	} `yaml:"file_contains"`
+	Sysctl *struct {
+		Key   string `yaml:"key"`
+		Value string `yaml:"value"`
+	} `yaml:"sysctl"`
+	Command *struct {
+		Run     string `yaml:"run"`
+		Matches string `yaml:"matches"`
+	} `yaml:"command"`
+	Expect *bool  `yaml:"expect"`
+	Reason string `yaml:"reason"`
+}
+
+// LoadYAML reads the host controls defined in the *.yaml and *.yml files of
+// dir, in file name order:
+//
+//	controls:
+//	  - id: "custom.1.1"
+//	    title: "Ensure IP forwarding is disabled"
+//	    metadata:
+//	      severity: medium
+//	    applies_to: [debian, rhel]
+//	    checks:
+//	      - key: ip_forward
+//	        sysctl: {key: net.ipv4.ip_forward, value: "0"}
+//	      - key: telnet_installed
+//	        package_installed: telnet
+//	        expect: false
+//	    pass: all
+//	    evidence: [ip_forward]
+func LoadYAML(dir string) ([]cisctl.Control, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []cisctl.Control
+	seen := map[string]string{}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var doc struct {
+			Controls []yamlControlDef `yaml:"controls"`
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for i, def := range doc.Controls {
+			c, err := newYAMLControl(def, path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: controls[%d] (id %q): %w", path, i, def.ID, err)
+			}
+			if prev, ok := seen[c.ID()]; ok {
+				return nil, fmt.Errorf("%s: control %s is already defined in %s", path, c.ID(), prev)
+			}
+			seen[c.ID()] = path
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// yamlControl is a host control defined in YAML. Its checks run in order;
+// each compares the output of one shell command with the expected value.
+type yamlControl struct {
+	def      yamlControlDef
+	source   string
+	checks   []yamlCheck
+	evidence []string
+}
+
+type yamlCheck struct {
+	key    string
+	kind   string
+	target string
+	cmd    string
+	want   string
+	match  *regexp.Regexp
+	expect bool
+	reason string
+}
+
+func newYAMLControl(def yamlControlDef, source string) (yamlControl, error) {
+	c := yamlControl{def: def, source: source}
+	if strings.TrimSpace(def.ID) == "" {
+		return c, errors.New("id is required")
+	}
+	if strings.TrimSpace(def.Title) == "" {
+		return c, errors.New("title is required")
+	}
+	if def.Pass != "" && def.Pass != "all" && def.Pass != "any" {
+		return c, fmt.Errorf("invalid pass %q (want all or any)", def.Pass)
+	}
+	if len(def.Checks) == 0 {
+		return c, errors.New("at least one check is required")
+	}
+	for i, cd := range def.Checks {
+		check, err := newYAMLCheck(cd)
+		if err != nil {
+			return c, fmt.Errorf("checks[%d]: %w", i, err)
+		}
+		if slices.ContainsFunc(c.checks, func(o yamlCheck) bool { return o.key == check.key }) {
+			return c, fmt.Errorf("checks[%d]: duplicate key %q", i, check.key)
+		}
+		c.checks = append(c.checks, check)
+	}
+	c.evidence = def.Evidence
+	if len(c.evidence) == 0 {
+		for _, check := range c.checks {
+			c.evidence = append(c.evidence, check.key)
+		}
+	}
+	for _, k := range c.evidence {
+		if !slices.ContainsFunc(c.checks, func(o yamlCheck) bool { return o.key == k }) {
+			return c, fmt.Errorf("evidence key %q is not a check key", k)
+		}
+	}
+	return c, nil
+}
+
+func newYAMLCheck(cd yamlCheckDef) (yamlCheck, error) {
+	check := yamlCheck{key: strings.TrimSpace(cd.Key), expect: true, want: "yes", reason: cd.Reason}
+	if check.key == "" {
+		return check, errors.New("key is required")
+	}
+	if check.key == "os" || check.key == "os_family" {
+		return check, fmt.Errorf("key %q is reserved", check.key)
+	}
+	if cd.Expect != nil {
+		check.expect = *cd.Expect
+	}
+
+	kinds := 0
+	if p := strings.TrimSpace(cd.PackageInstalled); p != "" {
+		kinds++
+		check.kind, check.target = "package_installed", p
+		check.cmd = fmt.Sprintf(`(dpkg -s %[1]s || rpm -q %[1]s) >/dev/null 2>&1 && echo yes || echo no`, shellQuote(p))
+	}
+	if s := strings.TrimSpace(cd.ServiceEnabled); s != "" {
+		kinds++
+		check.kind, check.target = "service_enabled", s
+		check.cmd = fmt.Sprintf(`systemctl is-enabled %s >/dev/null 2>&1 && echo yes || echo no`, shellQuote(s))
+	}
+	if s := strings.TrimSpace(cd.ServiceActive); s != "" {
+		kinds++
+		check.kind, check.target = "service_active", s
+		check.cmd = fmt.Sprintf(`systemctl is-active %s >/dev/null 2>&1 && echo yes || echo no`, shellQuote(s))
+	}
+	if fc := cd.FileContains; fc != nil {
+		kinds++
+		if fc.Path == "" || fc.Regex == "" {
+			return check, errors.New("file_contains needs path and regex")
+		}
+		// The file is matched here, with the same RE2 syntax that was
+		// validated, and line by line like grep.
+		re, err := regexp.Compile("(?m)" + fc.Regex)
+		if err != nil {
+			return check, fmt.Errorf("file_contains: %w", err)
+		}
+		check.kind, check.target = "file_contains", fc.Path
+		check.want, check.match = fc.Regex, re
+		// A missing or unreadable file matches nothing.
+		check.cmd = fmt.Sprintf(`cat -- %s 2>/dev/null || true`, shellQuote(fc.Path))
+	}
+	if sc := cd.Sysctl; sc != nil {
+		kinds++
+		if sc.Key == "" {
+			return check, errors.New("sysctl needs key")
+		}
+		check.kind, check.target = "sysctl", sc.Key
+		check.want = strings.Join(strings.Fields(sc.Value), " ")
+		check.cmd = fmt.Sprintf(`sysctl -n %s 2>/dev/null || true`, shellQuote(sc.Key))
+	}
+	if cc := cd.Command; cc != nil {
+		kinds++
+		if cc.Run == "" || cc.Matches == "" {
+			return check, errors.New("command needs run and matches")
+		}
+		re, err := regexp.Compile(cc.Matches)
+		if err != nil {
+			return check, fmt.Errorf("command: %w", err)
+		}
+		check.kind, check.target = "command", cc.Run
+		check.want, check.match = cc.Matches, re
+		// The exit status is ignored so that only a lost connection fails
+		// the command.
+		check.cmd = fmt.Sprintf(`sh -c %s 2>&1 || true`, shellQuote(cc.Run))
+	}
+	if kinds != 1 {
+		return check, errors.New("set exactly one of package_installed, service_enabled, service_active, file_contains, sysctl and command")
+	}
+	return check, nil
+}
+
+// eval reports whether the output of the check's command meets the check,
+// and the value recorded as evidence.
+func (c yamlCheck) eval(out string) (bool, string) {
+	var ok bool
+	value := out
+	switch c.kind {
+	case "sysctl":
+		value = strings.Join(strings.Fields(out), " ")
+		ok = value == c.want
+		if value == "" {
+			value = "unset"
+		}
+	case "file_contains":
+		ok = c.match.MatchString(out)
+		value = yesNo(ok)
+	case "command":
+		ok = c.match.MatchString(out)
+		if len(value) > 200 {
+			value = value[:200] + "..."
+		}
+	default:
+		ok = out == "yes"
+	}
+	return ok == c.expect, value
+}
+
+// failReason explains a failed check; value is its evidence.
+func (c yamlCheck) failReason(value string) string {
+	if c.reason != "" {
+		return c.reason
+	}
+	not := func(negated string, plain string) string {
+		if c.expect {
+			return negated
+		}
+		return plain
+	}
+	switch c.kind {
+	case "package_installed":
+		return fmt.Sprintf("package %s %s", c.target, not("not installed", "installed"))
+	case "service_enabled":
+		return fmt.Sprintf("service %s %s", c.target, not("not enabled", "enabled"))
+	case "service_active":
+		return fmt.Sprintf("service %s %s", c.target, not("not running", "running"))
+	case "file_contains":
+		return fmt.Sprintf("%s %s %s", c.target, not("does not match", "matches"), c.want)
+	case "sysctl":
+		if c.expect {
+			return fmt.Sprintf("sysctl %s is %s, want %s", c.target, value, c.want)
+		}
+		return fmt.Sprintf("sysctl %s is %s", c.target, value)
+	default:
+		return fmt.Sprintf("output of %q %s %s", c.target, not("does not match", "matches"), c.want)
+	}
+}
+
+func (c yamlControl) ID() string    { return c.def.ID }
+func (c yamlControl) Title() string { return c.def.Title }
+
+func (yamlControl) HostControl() {}
+
+func (c yamlControl) Run(ctx context.Context, deps cisctl.Deps) (cisctl.ControlOutcome, error) {
+	var out cisctl.ControlOutcome
+
+	hosts, err := deps.Hosts(ctx)
+	if err != nil {
+		return out, err
+	}
+	if len(hosts) == 0 {
+		out.Findings = append(out.Findings, noHostsFinding(deps))
+		return out, nil
+	}
+
+	for _, h := range hosts {
+		ip := h.Address
+		if strings.TrimSpace(ip) == "" {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				Pass:         false,
+				Reason:       "No public IPv4 address",
+			})
+			continue
+		}
+
+		osr, distro, known, err := detectDistro(deps, h)
+		if err != nil {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				IP:           ip,
+				Pass:         false,
+				Reason:       fmt.Sprintf("SSH unreachable: %v", err),
+			})
+			continue
+		}
+		if len(c.def.AppliesTo) > 0 && !osr.is(c.def.AppliesTo...) {
+			f := notApplicableFinding(h, osr)
+			f.Reason = fmt.Sprintf("distribution %s is not one of %s", osr, strings.Join(c.def.AppliesTo, ", "))
+			out.Findings = append(out.Findings, f)
+			continue
+		}
+
+		f := cisctl.Finding{
+			ResourceType: h.ResourceType,
+			ResourceID:   h.ResourceID,
+			ResourceName: h.Name,
+			IP:           ip,
+			Evidence:     map[string]string{"os": osr.String()},
+		}
+		if known {
+			f.Evidence["os_family"] = distro.Family
+		}
+		passed := 0
+		var reasons []string
+		var cmdErr error
+		for _, check := range c.checks {
+			res, err := sshCommand(deps, h, check.cmd)
+			if err != nil {
+				cmdErr = err
+				break
+			}
+			ok, value := check.eval(res)
+			if slices.Contains(c.evidence, check.key) {
+				f.Evidence[check.key] = value
+			}
+			if ok {
+				passed++
+			} else {
+				reasons = append(reasons, check.failReason(value))
+			}
+		}
+		if cmdErr != nil {
+			out.Findings = append(out.Findings, cisctl.Finding{
+				ResourceType: h.ResourceType,
+				ResourceID:   h.ResourceID,
+				ResourceName: h.Name,
+				IP:           ip,
+				Pass:         false,
+				Reason:       fmt.Sprintf("SSH command failed: %v", cmdErr),
+			})
+			continue
+		}
+
+		if c.def.Pass == "any" {
+			f.Pass = passed > 0
+		} else {
+			f.Pass = passed == len(c.checks)
+		}
+		if !f.Pass {
+			f.Reason = strings.Join(reasons, "; ")
+			if deps.Log != nil {
+				deps.Log.WithFinding(f).Error("host check failed", "reason", f.Reason)
+			}
+		} else if deps.Log != nil {
+			deps.Log.WithFinding(f).Info("host check passed")
+		}
+
+		out.Findings = append(out.Findings, f)
+	}
+
+	return out, nil
+}
+
+func (c yamlControl) Describe() cisctl.ControlDescription {
+	commands := []string{cmdOSRelease}
+	for _, check := range c.checks {
+		commands = append(commands, check.cmd)
+	}
+	return cisctl.ControlDescription{
+		ResourceTypes: []string{"droplet", "host"},
+		APICalls:      []string{apiListDroplets},
+		Commands:      commands,
+		EvidenceKeys:  append([]string{"os", "os_family"}, c.evidence...),
+		Source:        c.source,
+		Metadata:      c.def.Metadata,
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	app := cisctl.NewApp(controls.All())
+	app := cisctl.NewApp(controls.All(), controls.Plan(), controls.LoadYAML)
 	os.Exit(app.Run(context.Background(), os.Args[1:]))
 }
 